@@ -0,0 +1,155 @@
+// commands.go - Pluggable command registry used by processCommand
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// CommandHandler - Handles a command and returns the text reply ("" when media/action was already sent)
+type CommandHandler func(ctx *CommandContext) string
+
+// Command - A chat command that can be registered with the bot
+type Command interface {
+	Name() string
+	Aliases() []string
+	Description() string
+	Usage() string
+	Examples() []string
+	GroupOnly() bool
+	MinRole() Role
+	Cooldown() time.Duration // per-sender window between uses
+	Legacy() bool            // also reachable through the old "/" prefix
+	Handle(ctx *CommandContext) string
+}
+
+// CommandContext - Everything a command handler needs about the incoming message
+type CommandContext struct {
+	Bot     *WhatsAppBot
+	ChatJID types.JID
+	Sender  types.JID
+	Message *events.Message
+	Prefix  string   // prefix the command was sent with ("." or "/")
+	Invoked string   // command name as typed, without prefix (e.g. "s")
+	Args    []string // arguments after the command name
+	IsGroup bool
+	Policy  *GroupPolicy
+
+	base, role                 Role // caller role cache, see CallerRole
+	baseResolved, roleResolved bool
+}
+
+// RawArgs - Arguments joined back into a single string
+func (ctx *CommandContext) RawArgs() string {
+	return strings.Join(ctx.Args, " ")
+}
+
+// BasicCommand - Struct based Command implementation for simple handlers
+type BasicCommand struct {
+	CmdName        string
+	CmdAliases     []string
+	CmdDescription string
+	CmdUsage       string
+	CmdExamples    []string
+	IsGroupOnly    bool
+	MinimumRole    Role
+	CmdCooldown    time.Duration
+	AllowLegacy    bool
+	Handler        CommandHandler
+}
+
+func (c *BasicCommand) Name() string            { return c.CmdName }
+func (c *BasicCommand) Aliases() []string       { return c.CmdAliases }
+func (c *BasicCommand) Description() string     { return c.CmdDescription }
+func (c *BasicCommand) Examples() []string      { return c.CmdExamples }
+func (c *BasicCommand) GroupOnly() bool         { return c.IsGroupOnly }
+func (c *BasicCommand) MinRole() Role           { return c.MinimumRole }
+func (c *BasicCommand) Cooldown() time.Duration { return c.CmdCooldown }
+func (c *BasicCommand) Legacy() bool            { return c.AllowLegacy }
+
+func (c *BasicCommand) Usage() string {
+	if c.CmdUsage == "" {
+		return "." + c.CmdName
+	}
+	return c.CmdUsage
+}
+
+func (c *BasicCommand) Handle(ctx *CommandContext) string {
+	if c.Handler == nil {
+		return ""
+	}
+	return c.Handler(ctx)
+}
+
+// CommandRegistry - Lookup table of commands by name and alias
+type CommandRegistry struct {
+	mutex    sync.RWMutex
+	byName   map[string]Command
+	commands []Command
+}
+
+// NewCommandRegistry - Create an empty command registry
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		byName: make(map[string]Command),
+	}
+}
+
+// Register - Add a command; panics on duplicate names so conflicts show up at startup
+func (r *CommandRegistry) Register(cmd Command) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := append([]string{cmd.Name()}, cmd.Aliases()...)
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if existing, ok := r.byName[key]; ok {
+			panic(fmt.Sprintf("command %q already registered by %q", key, existing.Name()))
+		}
+	}
+	for _, name := range names {
+		r.byName[strings.ToLower(name)] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+// Lookup - Find a command by name or alias (without prefix)
+func (r *CommandRegistry) Lookup(name string) (Command, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cmd, ok := r.byName[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands - All registered commands in registration order (menu order)
+func (r *CommandRegistry) Commands() []Command {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	list := make([]Command, len(r.commands))
+	copy(list, r.commands)
+	return list
+}
+
+// defaultRegistry - Commands registered from init() in each command file
+var defaultRegistry = NewCommandRegistry()
+
+// registerCommand - Register a command with the default registry
+func registerCommand(cmd Command) {
+	defaultRegistry.Register(cmd)
+}
+
+// splitCommand - Split "<prefix><name> args..." into the lowercase name and args
+func splitCommand(text, prefix string) (name string, args []string) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(text), prefix))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), fields[1:]
+}
@@ -0,0 +1,230 @@
+// commands_builtin.go - Built-in bot commands (menu, stickers, tagall, info)
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "hi",
+		CmdDescription: "menu utama",
+		Handler:        hiCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "help",
+		CmdDescription: "bantuan lengkap",
+		CmdUsage:       ".help [command]",
+		CmdExamples:    []string{".help", ".help sticker"},
+		AllowLegacy:    true,
+		Handler:        helpCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "sticker",
+		CmdAliases:     []string{"s"},
+		CmdDescription: "konversi gambar/gif/video ke stiker WebP (ANIMATED!)",
+		CmdUsage:       ".sticker [all [jumlah]] [crop|fill|circle] [bg=warna] [q=1-100] [mm:ss detik] [2x] [pack|author] (reply gambar/gif/video)",
+		CmdExamples:    []string{"reply GIF lalu ketik .s", ".s crop", ".s circle bg=white", ".s q=60", ".s 00:12 3", ".s 01:05 4 2x", ".s all", ".s all 5 crop", "kirim album dengan caption .s", ".s Stiker Kelas|Budi"},
+		CmdCooldown:    5 * time.Second,
+		AllowLegacy:    true,
+		Handler:        stickerCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "toimg",
+		CmdDescription: "konversi stiker ke gambar PNG (stiker animasi jadi video MP4)",
+		CmdUsage:       ".toimg (reply stiker)",
+		CmdCooldown:    5 * time.Second,
+		Handler:        toImageCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "togif",
+		CmdDescription: "konversi stiker animasi ke GIF",
+		CmdUsage:       ".togif (reply stiker animasi)",
+		CmdCooldown:    5 * time.Second,
+		Handler:        toImageCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "tagall",
+		CmdDescription: "mention semua member",
+		CmdUsage:       ".tagall (bisa reply pesan)",
+		CmdExamples:    []string{".tagall", "reply pengumuman lalu ketik .tagall"},
+		IsGroupOnly:    true,
+		MinimumRole:    RoleGroupAdmin,
+		CmdCooldown:    30 * time.Second,
+		AllowLegacy:    true,
+		Handler:        tagAllCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "calendar",
+		CmdDescription: "info tanggal hari ini WIB",
+		Handler: func(ctx *CommandContext) string {
+			return ctx.Bot.getCalendarInfo()
+		},
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "stats",
+		CmdDescription: "statistik bot",
+		Handler:        statsCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "tools",
+		CmdDescription: "cek status WebP tools",
+		Handler: func(ctx *CommandContext) string {
+			return ctx.Bot.getToolsStatus()
+		},
+	})
+}
+
+func stickerCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	// .s all [N] - the last N images/videos sent in this chat
+	args := ctx.Args
+	batchCount := 0
+	if len(args) > 0 && (strings.EqualFold(args[0], "all") || strings.EqualFold(args[0], "semua")) {
+		batchCount = recentMediaPerChat
+		args = args[1:]
+		if len(args) > 0 {
+			if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+				batchCount = min(n, recentMediaPerChat)
+				args = args[1:]
+			}
+		}
+	}
+
+	opts, metaText, err := parseStickerOptions(args)
+	if err != nil {
+		return fmt.Sprintf("%s. contoh: %ss crop q=60, %ss circle bg=white, %ss 00:12 3, %ss Pack|Author", err.Error(), prefix, prefix, prefix, prefix)
+	}
+
+	// .s pack|author saves the sender's pack info for this and future stickers
+	packName, publisher, hasMeta := parseStickerMetaArg(metaText)
+	if hasMeta {
+		if err := bot.saveStickerMetadata(ctx.Sender.User, packName, publisher); err != nil {
+			fmt.Printf("❌ Failed to save sticker metadata: %v\n", err)
+			return "yah gagal simpan nama pack. coba lagi ya"
+		}
+	}
+
+	if batchCount > 0 {
+		items := bot.recentMedia.Last(ctx.ChatJID, batchCount)
+		if len(items) == 0 {
+			return fmt.Sprintf("belum ada gambar/video di chat ini 10 menit terakhir. kirim dulu, terus ketik %ss all", prefix)
+		}
+		opts.Meta = bot.stickerMetadataFor(ctx.Sender.User)
+		return bot.queueStickerBatch(ctx, len(items), opts, func(context.Context) []*events.Message {
+			return items
+		})
+	}
+
+	// .s as the caption of an album stickers the whole album
+	if albumID := albumIDOf(ctx.Message); albumID != "" {
+		opts.Meta = bot.stickerMetadataFor(ctx.Sender.User)
+		return bot.queueStickerBatch(ctx, recentMediaPerChat, opts, func(jobCtx context.Context) []*events.Message {
+			return bot.waitForAlbum(jobCtx, ctx.ChatJID, albumID)
+		})
+	}
+
+	if !bot.hasQuotedImage(ctx.Message) {
+		if hasMeta {
+			meta := bot.stickerMetadataFor(ctx.Sender.User)
+			return fmt.Sprintf("✅ stiker kamu selanjutnya pake pack *%s* by *%s*", meta.PackName, meta.Publisher)
+		}
+		return "reply gambar, gif, atau video dulu dong biar bisa dijadiin stiker WebP (animated!)"
+	}
+
+	opts.Meta = bot.stickerMetadataFor(ctx.Sender.User)
+	return bot.queueMediaCommand(ctx, bot.mediaPriority(ctx), func(jobCtx context.Context) string {
+		return bot.StickerHandler(jobCtx, ctx.Sender, ctx.Message, opts)
+	})
+}
+
+func toImageCommand(ctx *CommandContext) string {
+	if !ctx.Bot.hasQuotedSticker(ctx.Message) {
+		return "reply stiker dulu biar bisa dikonversi ke gambar"
+	}
+	gifPlayback := ctx.Invoked == "togif"
+	priority := mediaPriorityImage
+	if gifPlayback {
+		priority = mediaPriorityGIF
+	}
+	return ctx.Bot.queueMediaCommand(ctx, priority, func(jobCtx context.Context) string {
+		return ctx.Bot.ToImageHandler(jobCtx, ctx.Sender, ctx.Message, gifPlayback)
+	})
+}
+
+func tagAllCommand(ctx *CommandContext) string {
+	quotedText := ctx.Bot.extractQuotedMessageText(ctx.Message)
+	return ctx.Bot.TagAllHandler(ctx.ChatJID, ctx.Message.Info.ID, quotedText)
+}
+
+func statsCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+
+	bot.mutex.RLock()
+	count := bot.processedMessages
+	bot.mutex.RUnlock()
+	uptime := time.Since(bot.startTime)
+
+	// Calculate messages per minute
+	minutes := uptime.Minutes()
+	msgPerMin := float64(0)
+	if minutes > 0 {
+		msgPerMin = float64(count) / minutes
+	}
+
+	// Check animation support
+	animationSupport := "❌ disabled"
+	if bot.isToolAvailable("gif2webp") {
+		animationSupport = "✅ gif2webp"
+	} else if bot.isToolAvailable("ffmpeg") {
+		animationSupport = "⚠️ ffmpeg only"
+	}
+
+	queue := bot.mediaQueue.Stats()
+
+	cacheText := "❌ disabled"
+	if bot.mediaCache != nil {
+		cache := bot.mediaCache.Stats()
+		cacheText = fmt.Sprintf("*%d* file (%.1f/%d MB), hit rate *%.0f%%* (%d/%d), upload dihemat *%d*",
+			cache.Entries, float64(cache.Bytes)/(1024*1024), cache.MaxBytes/(1024*1024),
+			cache.HitRate(), cache.Hits, cache.Hits+cache.Misses, cache.UploadHits)
+	}
+
+	extraText := ""
+	if ctx.Policy.Exclusive {
+		extraText = fmt.Sprintf("\nbot eksklusif untuk %s! 💎", ctx.Policy.Name)
+	}
+
+	return fmt.Sprintf(`📊 *Bot Statistics*
+
+💬 pesan diproses: *%d*
+⏱️ uptime: *%v*
+📈 rata-rata: *%.1f* msg/menit
+🎞️ animated stickers: %s
+🧵 media workers: *%d* (sibuk %d)
+📥 antrian media: *%d* job (selesai %d)
+⏳ tunggu antrian: rata-rata *%v*, max *%v*
+🗃️ cache media: %s
+⚡ mode: WebP + concurrent processing
+🚀 response time: < 500ms
+📱 status: online & ready%s
+
+keep sending GIFs! 🎞️🤖`,
+		count,
+		uptime.Truncate(time.Second),
+		msgPerMin,
+		animationSupport,
+		queue.Workers, queue.Busy,
+		queue.Queued, queue.Processed,
+		queue.AvgWait.Truncate(time.Millisecond), queue.MaxWait.Truncate(time.Millisecond),
+		cacheText,
+		extraText)
+}
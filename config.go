@@ -0,0 +1,259 @@
+// config.go - Bot configuration file and per-group policies keyed by group JID
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// configPath - Bot configuration file, next to session.db
+const configPath = "config.json"
+
+// defaultPrefixes - Prefixes accepted when a policy doesn't say otherwise
+var defaultPrefixes = []string{".", "/"}
+
+// GroupPolicy - How the bot behaves in a specific chat
+type GroupPolicy struct {
+	Name             string   `json:"name"`              // display name used in menus and logs
+	Exclusive        bool     `json:"exclusive"`         // exclusive group branding in menus
+	Prefixes         []string `json:"prefixes"`          // accepted command prefixes, first one is primary
+	EnabledCommands  []string `json:"enabled_commands"`  // allow-list, empty means every command
+	DisabledCommands []string `json:"disabled_commands"` // deny-list applied after the allow-list
+	Greeting         string   `json:"greeting"`          // custom .hi header
+	SilentBlock      bool     `json:"silent_block"`      // ignore blocked commands instead of replying
+
+	CommandOverrides map[string]bool `json:"-"` // per-command switches saved by group admins
+}
+
+// BotConfig - Contents of config.json
+type BotConfig struct {
+	Owners  []string                `json:"owners"`  // bot owner phone numbers or JIDs
+	Admins  []string                `json:"admins"`  // bot-wide admins
+	Default GroupPolicy             `json:"default"` // DMs and groups without their own entry
+	Groups  map[string]*GroupPolicy `json:"groups"`  // keyed by group JID (xxx@g.us)
+	Limits  LimitConfig             `json:"limits"`
+	Media   MediaConfig             `json:"media"`
+	Sticker StickerConfig           `json:"sticker"`
+}
+
+// StickerConfig - Default pack metadata embedded in generated stickers
+type StickerConfig struct {
+	PackName  string   `json:"pack_name"`
+	Publisher string   `json:"publisher"`
+	Emojis    []string `json:"emojis"`
+}
+
+// applyDefaults - Fill zero values with the built-in defaults
+func (s *StickerConfig) applyDefaults() {
+	if s.PackName == "" {
+		s.PackName = "WhatsApp Bot Stickers"
+	}
+	if s.Publisher == "" {
+		s.Publisher = "WhatsApp Bot"
+	}
+}
+
+// MediaConfig - Conversion backends and output size caps
+type MediaConfig struct {
+	WebPBackend          string `json:"webp_backend"`            // "native" (default, pure Go) or "cli" (cwebp/dwebp)
+	AnimatedStickerMaxKB int    `json:"animated_sticker_max_kb"` // size cap for animated stickers (WhatsApp rejects ~500KB+)
+	CacheDir             string `json:"cache_dir"`               // converted media cache, next to bot.db by default
+	CacheMaxMB           int    `json:"cache_max_mb"`            // cache size before LRU eviction, negative disables it
+}
+
+// applyDefaults - Fill zero values with the built-in defaults
+func (m *MediaConfig) applyDefaults() {
+	if m.WebPBackend != webpBackendCLI {
+		m.WebPBackend = webpBackendNative
+	}
+	if m.AnimatedStickerMaxKB <= 0 {
+		m.AnimatedStickerMaxKB = 500
+	}
+	if m.CacheDir == "" {
+		m.CacheDir = "media_cache"
+	}
+	if m.CacheMaxMB == 0 {
+		m.CacheMaxMB = 200
+	}
+}
+
+// AnimatedStickerMaxBytes - Size budget for animated stickers
+func (m *MediaConfig) AnimatedStickerMaxBytes() int {
+	return m.AnimatedStickerMaxKB * 1024
+}
+
+// CacheMaxBytes - Media cache size cap, <= 0 when caching is off
+func (m *MediaConfig) CacheMaxBytes() int64 {
+	return int64(m.CacheMaxMB) * 1024 * 1024
+}
+
+// LimitConfig - Concurrency caps and cooldown windows
+type LimitConfig struct {
+	MaxConcurrent         int                `json:"max_concurrent"`          // messages handled at once (was the 50-slot limiter)
+	PerSender             int                `json:"per_sender"`              // concurrent messages per sender
+	PerChat               int                `json:"per_chat"`                // concurrent messages per chat
+	MediaWorkers          int                `json:"media_workers"`           // media conversion workers (default: CPU count)
+	MediaTimeoutSeconds   float64            `json:"media_timeout_seconds"`   // deadline for one media job
+	ToolMemoryMB          int                `json:"tool_memory_mb"`          // address space limit per tool process
+	ToolCPUSeconds        int                `json:"tool_cpu_seconds"`        // CPU time limit per tool process
+	ToolMaxFileMB         int                `json:"tool_max_file_mb"`        // largest file a tool may write
+	SenderCooldownSeconds float64            `json:"sender_cooldown_seconds"` // gap between any two commands of a sender
+	CommandCooldowns      map[string]float64 `json:"command_cooldowns"`       // per-command override in seconds
+}
+
+// applyDefaults - Fill zero values with the built-in defaults
+func (l *LimitConfig) applyDefaults() {
+	if l.MaxConcurrent <= 0 {
+		l.MaxConcurrent = 50
+	}
+	if l.PerSender <= 0 {
+		l.PerSender = 2
+	}
+	if l.PerChat <= 0 {
+		l.PerChat = 10
+	}
+	if l.MediaWorkers <= 0 {
+		l.MediaWorkers = runtime.NumCPU()
+	}
+	if l.MediaTimeoutSeconds <= 0 {
+		l.MediaTimeoutSeconds = 90
+	}
+	if l.ToolMemoryMB <= 0 {
+		l.ToolMemoryMB = 2048
+	}
+	if l.ToolCPUSeconds <= 0 {
+		l.ToolCPUSeconds = 60
+	}
+	if l.ToolMaxFileMB <= 0 {
+		l.ToolMaxFileMB = 100
+	}
+	if l.SenderCooldownSeconds <= 0 {
+		l.SenderCooldownSeconds = 1
+	}
+}
+
+// SenderCooldown - Window between any two commands from the same sender
+func (l *LimitConfig) SenderCooldown() time.Duration {
+	return time.Duration(l.SenderCooldownSeconds * float64(time.Second))
+}
+
+// MediaTimeout - Deadline for a single media job
+func (l *LimitConfig) MediaTimeout() time.Duration {
+	return time.Duration(l.MediaTimeoutSeconds * float64(time.Second))
+}
+
+// CommandCooldown - Configured window for a command, falling back to its declared cooldown
+func (l *LimitConfig) CommandCooldown(cmd Command) time.Duration {
+	if seconds, ok := l.CommandCooldowns[cmd.Name()]; ok {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return cmd.Cooldown()
+}
+
+// LoadConfig - Read config.json; a missing file gives the built-in defaults
+func LoadConfig(path string) (*BotConfig, error) {
+	config := &BotConfig{
+		Groups: make(map[string]*GroupPolicy),
+	}
+	defer config.Limits.applyDefaults()
+	defer config.Media.applyDefaults()
+	defer config.Sticker.applyDefaults()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("⚠️ %s not found - using default policy for all chats\n", path)
+		return config, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("gagal baca config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("config %s tidak valid: %v", path, err)
+	}
+	if config.Groups == nil {
+		config.Groups = make(map[string]*GroupPolicy)
+	}
+
+	fmt.Printf("✅ Loaded %s (%d group policies)\n", path, len(config.Groups))
+	return config, nil
+}
+
+// PolicyFor - Resolve the policy for a chat, falling back to the default policy
+func (c *BotConfig) PolicyFor(chatJID types.JID) *GroupPolicy {
+	policy := c.Default
+	if group, ok := c.Groups[chatJID.String()]; ok && group != nil {
+		policy = *group
+	}
+
+	if len(policy.Prefixes) == 0 {
+		policy.Prefixes = defaultPrefixes
+	}
+	return &policy
+}
+
+// PrimaryPrefix - Prefix shown in menus
+func (p *GroupPolicy) PrimaryPrefix() string {
+	if len(p.Prefixes) == 0 {
+		return defaultPrefixes[0]
+	}
+	return p.Prefixes[0]
+}
+
+// AcceptsPrefix - Whether commands with this prefix are handled in the chat
+func (p *GroupPolicy) AcceptsPrefix(prefix string) bool {
+	for _, accepted := range p.Prefixes {
+		if accepted == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPrefix - Prefix the text starts with ("" if it's not a command). Accepted prefixes
+// are checked first; "." and "/" are always recognized so blocked commands can be answered.
+func (p *GroupPolicy) MatchPrefix(text string) string {
+	candidates := append(append([]string{}, p.Prefixes...), defaultPrefixes...)
+	for _, prefix := range candidates {
+		if prefix != "" && strings.HasPrefix(text, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// IsCommandEnabled - Whether a command (by canonical name) may run in the chat. Group admin
+// overrides can only switch off what the operator's config allows, never turn it back on.
+func (p *GroupPolicy) IsCommandEnabled(name string) bool {
+	if !p.ConfigAllowsCommand(name) {
+		return false
+	}
+	if enabled, ok := p.CommandOverrides[strings.ToLower(name)]; ok {
+		return enabled
+	}
+	return true
+}
+
+// ConfigAllowsCommand - The enabled_commands/disabled_commands check from config.json alone
+func (p *GroupPolicy) ConfigAllowsCommand(name string) bool {
+	if len(p.EnabledCommands) > 0 && !containsFold(p.EnabledCommands, name) {
+		return false
+	}
+	return !containsFold(p.DisabledCommands, name)
+}
+
+// containsFold - Case-insensitive slice membership
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
@@ -14,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,12 +27,24 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-// StickerHandler - Enhanced with animated WebP support
-func (bot *WhatsAppBot) StickerHandler(sender types.JID, msg *events.Message) string {
-	fmt.Printf("🎨 PROCESSING: Converting to sticker for +%s\n", sender.User)
+// StickerHandler - Enhanced with animated WebP support, layout options and pack metadata
+func (bot *WhatsAppBot) StickerHandler(ctx context.Context, sender types.JID, msg *events.Message, opts StickerOptions) string {
+	fmt.Printf("🎨 PROCESSING: Converting to sticker for +%s (%s)\n", sender.User, opts.Describe())
+
+	// Same media with the same options was converted before - skip download and conversion
+	cacheKey := bot.stickerCacheKey(msg, opts)
+	if cached, ok := bot.mediaCache.Get(cacheKey); ok {
+		err := bot.sendStickerWithMetadata(msg.Info.Chat, cached.Data, msg.Info.ID, cached.Animated, opts.Meta)
+		if err != nil {
+			fmt.Printf("❌ Failed to send sticker: %v\n", err)
+			return "yah gagal kirim stickernya. coba lagi deh"
+		}
+		fmt.Printf("✅ Cached sticker sent successfully to +%s\n", sender.User)
+		return ""
+	}
 
 	// Get image/video from message
-	mediaData, mediaType, err := bot.downloadMedia(msg)
+	mediaData, mediaType, err := bot.downloadMedia(ctx, msg)
 	if err != nil {
 		fmt.Printf("❌ Failed to download media: %v\n", err)
 		return "yah gagal download medianya nih. coba lagi ya"
@@ -40,13 +55,20 @@ func (bot *WhatsAppBot) StickerHandler(sender types.JID, msg *events.Message) st
 	// Convert to sticker based on media type
 	var stickerData []byte
 	var isAnimated bool = false
+	var fallback bool // static fallback after a failed animation, not worth caching
+	var tradeoffs string
 
 	if mediaType == "gif" {
 		// Try animated WebP first, fallback to static if failed
-		stickerData, isAnimated, err = bot.convertGifToAnimatedStickerWebP(mediaData)
+		stickerData, tradeoffs, err = bot.convertGifToAnimatedStickerWebP(ctx, mediaData, opts)
+		isAnimated = err == nil
+		if errors.Is(err, errGIFOptionsNeedFFmpeg) {
+			return "opsi crop/fill/circle/bg=, teks meme, potong durasi dan speed buat GIF butuh ffmpeg, tapi ffmpeg belum keinstall di server. coba tanpa opsi itu ya"
+		}
 		if err != nil {
 			fmt.Printf("⚠️ Animated conversion failed, trying static: %v\n", err)
-			stickerData, err = bot.convertGifToStaticStickerWebP(mediaData)
+			fallback = true
+			stickerData, err = bot.convertGifToStaticStickerWebP(ctx, mediaData, opts)
 			if err != nil {
 				fmt.Printf("❌ Failed to convert GIF to sticker: %v\n", err)
 				return "waduh gagal convert GIF ke sticker: " + err.Error()
@@ -54,10 +76,12 @@ func (bot *WhatsAppBot) StickerHandler(sender types.JID, msg *events.Message) st
 		}
 	} else if mediaType == "video" {
 		// For video files, try to convert to animated sticker
-		stickerData, isAnimated, err = bot.convertVideoToAnimatedStickerWebP(mediaData)
+		stickerData, tradeoffs, err = bot.convertVideoToAnimatedStickerWebP(ctx, mediaData, opts)
+		isAnimated = err == nil
 		if err != nil {
 			fmt.Printf("⚠️ Video animation failed, trying static frame: %v\n", err)
-			stickerData, err = bot.convertVideoToStaticStickerWebP(mediaData)
+			fallback = true
+			stickerData, err = bot.convertVideoToStaticStickerWebP(ctx, mediaData, opts)
 			if err != nil {
 				fmt.Printf("❌ Failed to convert video to sticker: %v\n", err)
 				return "waduh gagal convert video ke sticker: " + err.Error()
@@ -65,15 +89,19 @@ func (bot *WhatsAppBot) StickerHandler(sender types.JID, msg *events.Message) st
 		}
 	} else {
 		// Regular image (JPEG/PNG) - always static
-		stickerData, err = bot.convertToStickerWebP(mediaData)
+		stickerData, err = bot.convertToStickerWebP(ctx, mediaData, opts)
 		if err != nil {
 			fmt.Printf("❌ Failed to convert image to sticker: %v\n", err)
 			return "waduh gagal convert ke sticker: " + err.Error()
 		}
 	}
 
-	// Send sticker with animation flag
-	err = bot.sendSticker(msg.Info.Chat, stickerData, msg.Info.ID, isAnimated)
+	if !fallback {
+		bot.mediaCache.Put(cacheKey, cacheKindSticker, CachedMedia{Data: stickerData, Animated: isAnimated})
+	}
+
+	// Send sticker with pack metadata and animation flag
+	err = bot.sendStickerWithMetadata(msg.Info.Chat, stickerData, msg.Info.ID, isAnimated, opts.Meta)
 	if err != nil {
 		fmt.Printf("❌ Failed to send sticker: %v\n", err)
 		return "yah gagal kirim stickernya. coba lagi deh"
@@ -81,32 +109,72 @@ func (bot *WhatsAppBot) StickerHandler(sender types.JID, msg *events.Message) st
 
 	if isAnimated {
 		fmt.Printf("✅ Animated sticker sent successfully to +%s\n", sender.User)
+		if tradeoffs != "" {
+			bot.sendReply(msg.Info.Chat, fmt.Sprintf("ℹ️ biar muat di batas %d KB stiker animasi, stikernya dikecilin: %s",
+				bot.config.Media.AnimatedStickerMaxKB, tradeoffs), msg.Info.ID, sender)
+		}
 	} else {
 		fmt.Printf("✅ Static sticker sent successfully to +%s\n", sender.User)
 	}
 	return "" // Don't send text reply, sticker is already sent
 }
 
-// ToImageHandler - Convert sticker to image
-func (bot *WhatsAppBot) ToImageHandler(sender types.JID, msg *events.Message) string {
+// ToImageHandler - Convert sticker to image; animated stickers become an MP4 (gifPlayback for .togif)
+func (bot *WhatsAppBot) ToImageHandler(ctx context.Context, sender types.JID, msg *events.Message, gifPlayback bool) string {
 	fmt.Printf("🖼️ PROCESSING: Converting sticker to image for +%s\n", sender.User)
 
+	// .toimg and .togif convert the same way, only the send differs
+	cacheKey := mediaCacheKey(cacheKindImage, bot.mediaFileSHA256(msg), "")
+	if cached, ok := bot.mediaCache.Get(cacheKey); ok {
+		return bot.sendConvertedSticker(msg, sender, cached, gifPlayback)
+	}
+
 	// Get sticker from message
-	stickerData, err := bot.downloadSticker(msg)
+	stickerData, err := bot.downloadSticker(ctx, msg)
 	if err != nil {
 		fmt.Printf("❌ Failed to download sticker: %v\n", err)
 		return "yah gagal download stickernya. coba lagi ya"
 	}
 
-	// Convert to image (PNG)
-	imageData, err := bot.convertStickerToImageWebP(stickerData)
-	if err != nil {
-		fmt.Printf("❌ Failed to convert to image: %v\n", err)
-		return "waduh gagal convert ke gambar nih"
+	// Animated WebP - dwebp would only give the first frame
+	var converted CachedMedia
+	if isAnimatedWebP(stickerData) {
+		fmt.Printf("🎞️ Animated sticker detected - converting to MP4...\n")
+		videoData, seconds, err := bot.convertAnimatedStickerToMP4(ctx, stickerData)
+		if err != nil {
+			fmt.Printf("❌ Failed to convert animated sticker: %v\n", err)
+			return "waduh gagal convert stiker animasi ke video: " + err.Error()
+		}
+		converted = CachedMedia{Data: videoData, Animated: true, Seconds: seconds}
+	} else {
+		// Convert to image (PNG)
+		imageData, err := bot.convertStickerToImageWebP(ctx, stickerData)
+		if err != nil {
+			fmt.Printf("❌ Failed to convert to image: %v\n", err)
+			return "waduh gagal convert ke gambar nih"
+		}
+		converted = CachedMedia{Data: imageData}
+	}
+
+	bot.mediaCache.Put(cacheKey, cacheKindImage, converted)
+	return bot.sendConvertedSticker(msg, sender, converted, gifPlayback)
+}
+
+// sendConvertedSticker - Send a .toimg result: MP4 for animated stickers, image otherwise
+func (bot *WhatsAppBot) sendConvertedSticker(msg *events.Message, sender types.JID, converted CachedMedia, gifPlayback bool) string {
+	if converted.Animated {
+		err := bot.sendVideo(msg.Info.Chat, converted.Data, converted.Seconds, gifPlayback, msg.Info.ID)
+		if err != nil {
+			fmt.Printf("❌ Failed to send video: %v\n", err)
+			return "yah gagal kirim videonya. coba lagi deh"
+		}
+
+		fmt.Printf("✅ Video sent successfully to +%s\n", sender.User)
+		return ""
 	}
 
 	// Send image
-	err = bot.sendImage(msg.Info.Chat, imageData, "converted_image.png", msg.Info.ID)
+	err := bot.sendImage(msg.Info.Chat, converted.Data, "converted_image.png", msg.Info.ID)
 	if err != nil {
 		fmt.Printf("❌ Failed to send image: %v\n", err)
 		return "yah gagal kirim gambarnya. coba lagi deh"
@@ -116,15 +184,18 @@ func (bot *WhatsAppBot) ToImageHandler(sender types.JID, msg *events.Message) st
 	return ""
 }
 
-// downloadMedia - Download image/video/gif from WhatsApp message with type detection
-func (bot *WhatsAppBot) downloadMedia(msg *events.Message) ([]byte, string, error) {
+// downloadMedia - Download image/video/gif/audio from WhatsApp message with type detection
+func (bot *WhatsAppBot) downloadMedia(ctx context.Context, msg *events.Message) ([]byte, string, error) {
 	var imageMsg *waProto.ImageMessage
 	var videoMsg *waProto.VideoMessage
+	var audioMsg *waProto.AudioMessage
 
 	if msg.Message.GetImageMessage() != nil {
 		imageMsg = msg.Message.GetImageMessage()
 	} else if msg.Message.GetVideoMessage() != nil {
 		videoMsg = msg.Message.GetVideoMessage()
+	} else if msg.Message.GetAudioMessage() != nil {
+		audioMsg = msg.Message.GetAudioMessage()
 	} else {
 		extendedMsg := msg.Message.GetExtendedTextMessage()
 		if extendedMsg != nil {
@@ -136,6 +207,8 @@ func (bot *WhatsAppBot) downloadMedia(msg *events.Message) ([]byte, string, erro
 						imageMsg = quotedMsg.GetImageMessage()
 					} else if quotedMsg.GetVideoMessage() != nil {
 						videoMsg = quotedMsg.GetVideoMessage()
+					} else if quotedMsg.GetAudioMessage() != nil {
+						audioMsg = quotedMsg.GetAudioMessage()
 					}
 				}
 			}
@@ -144,7 +217,7 @@ func (bot *WhatsAppBot) downloadMedia(msg *events.Message) ([]byte, string, erro
 
 	if imageMsg != nil {
 		fmt.Printf("📥 Downloading image...\n")
-		data, err := bot.client.Download(context.Background(), imageMsg)
+		data, err := bot.client.Download(ctx, imageMsg)
 		if err != nil {
 			return nil, "", err
 		}
@@ -163,7 +236,7 @@ func (bot *WhatsAppBot) downloadMedia(msg *events.Message) ([]byte, string, erro
 
 	} else if videoMsg != nil {
 		fmt.Printf("📥 Downloading video/gif...\n")
-		data, err := bot.client.Download(context.Background(), videoMsg)
+		data, err := bot.client.Download(ctx, videoMsg)
 		if err != nil {
 			return nil, "", err
 		}
@@ -182,13 +255,21 @@ func (bot *WhatsAppBot) downloadMedia(msg *events.Message) ([]byte, string, erro
 		}
 
 		return data, mediaType, nil
+
+	} else if audioMsg != nil {
+		fmt.Printf("📥 Downloading audio...\n")
+		data, err := bot.client.Download(ctx, audioMsg)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "audio", nil
 	}
 
 	return nil, "", fmt.Errorf("no media found in message")
 }
 
 // downloadSticker - Download sticker from WhatsApp message
-func (bot *WhatsAppBot) downloadSticker(msg *events.Message) ([]byte, error) {
+func (bot *WhatsAppBot) downloadSticker(ctx context.Context, msg *events.Message) ([]byte, error) {
 	var stickerMsg *waProto.StickerMessage
 
 	if msg.Message.GetStickerMessage() != nil {
@@ -208,228 +289,183 @@ func (bot *WhatsAppBot) downloadSticker(msg *events.Message) ([]byte, error) {
 
 	if stickerMsg != nil {
 		fmt.Printf("📥 Downloading sticker...\n")
-		return bot.client.Download(context.Background(), stickerMsg)
+		return bot.client.Download(ctx, stickerMsg)
 	}
 
 	return nil, fmt.Errorf("no sticker found in message")
 }
 
-// convertGifToAnimatedStickerWebP - Convert GIF to animated WebP sticker
-func (bot *WhatsAppBot) convertGifToAnimatedStickerWebP(gifData []byte) ([]byte, bool, error) {
+// errGIFOptionsNeedFFmpeg - Layout, caption or clip options on a GIF while only gif2webp is installed
+var errGIFOptionsNeedFFmpeg = errors.New("GIF options need ffmpeg")
+
+// convertGifToAnimatedStickerWebP - Convert GIF to animated WebP sticker within the size budget;
+// also returns what had to be traded away to fit ("" when the first attempt did)
+func (bot *WhatsAppBot) convertGifToAnimatedStickerWebP(ctx context.Context, gifData []byte, opts StickerOptions) ([]byte, string, error) {
 	fmt.Printf("🎞️ Converting GIF to animated WebP sticker...\n")
 
 	// Check if tools are available
-	if !bot.isToolAvailable("gif2webp") && !bot.isToolAvailable("ffmpeg") {
-		return nil, false, fmt.Errorf("no animation tools available (gif2webp or ffmpeg needed)")
+	hasGif2WebP := bot.isToolAvailable("gif2webp")
+	hasFFmpeg := bot.isToolAvailable("ffmpeg")
+	if !hasGif2WebP && !hasFFmpeg {
+		return nil, "", fmt.Errorf("no animation tools available (gif2webp or ffmpeg needed)")
 	}
 
 	// Create temp directory
 	tempDir, err := ioutil.TempDir("", "gif_animated_sticker_*")
 	if err != nil {
-		return nil, false, fmt.Errorf("gagal create temp dir: %v", err)
+		return nil, "", fmt.Errorf("gagal create temp dir: %v", err)
 	}
 	defer os.RemoveAll(tempDir)
 
 	// Validate GIF
 	if len(gifData) < 6 || (string(gifData[0:6]) != "GIF87a" && string(gifData[0:6]) != "GIF89a") {
-		return nil, false, fmt.Errorf("bukan format GIF yang valid")
+		return nil, "", fmt.Errorf("bukan format GIF yang valid")
 	}
 
-	// Try gif2webp first (Google's official tool for animated WebP)
-	if bot.isToolAvailable("gif2webp") {
-		return bot.convertWithGif2WebP(gifData, tempDir)
+	inputPath := filepath.Join(tempDir, "input.gif")
+	err = ioutil.WriteFile(inputPath, gifData, 0644)
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal save input GIF: %v", err)
+	}
+
+	// crop/fill/circle/bg, meme captions and clip/speed need ffmpeg filters, gif2webp can only resize
+	needsFFmpeg := opts.NeedsFilters() || opts.HasClip()
+	if needsFFmpeg && !hasFFmpeg {
+		fmt.Printf("⚠️ ffmpeg not found - can't apply %s to a GIF\n", opts.Describe())
+		return nil, "", errGIFOptionsNeedFFmpeg
+	}
+	useGif2WebP := hasGif2WebP && !needsFFmpeg
+
+	// gif2webp (Google's official tool) keeps every frame and always fills the canvas, so it
+	// takes the full-size attempts at the source frame rate; dropping frames, trimming and
+	// shrinking the content need ffmpeg
+	const gifFPS = 15
+	clipSeconds := opts.ClipSeconds(0)
+	var lastGif2WebP stickerAttempt
+	ladder := stickerBudgetLadder(opts.QualityOr(75), gifFPS, clipSeconds)
+	webpData, attempt, err := bot.encodeWithinBudget(ctx, "GIF", ladder,
+		func(ctx context.Context, attempt stickerAttempt) ([]byte, error) {
+			if useGif2WebP && (!hasFFmpeg || (attempt.FPS == gifFPS && attempt.Seconds == clipSeconds)) {
+				if attempt.Size < stickerSize || attempt.Quality == lastGif2WebP.Quality {
+					return nil, errAttemptSkipped
+				}
+				lastGif2WebP = attempt
+				return bot.convertWithGif2WebP(ctx, inputPath, tempDir, attempt)
+			}
+			return bot.encodeAnimatedStickerFFmpeg(ctx, inputPath, tempDir, opts, attempt)
+		})
+	if err != nil {
+		return nil, "", err
 	}
-
-	// Fallback to FFmpeg
-	if bot.isToolAvailable("ffmpeg") {
-		return bot.convertWithFFmpegAnimated(gifData, tempDir)
+	if useGif2WebP && !hasFFmpeg {
+		attempt.FPS, attempt.Seconds = ladder[0].FPS, ladder[0].Seconds // gif2webp kept every frame
 	}
-
-	return nil, false, fmt.Errorf("no suitable animation conversion tool found")
+	return webpData, attempt.Tradeoffs(ladder[0]), nil
 }
 
 // convertWithGif2WebP - Use Google's gif2webp tool for best animated WebP
-func (bot *WhatsAppBot) convertWithGif2WebP(gifData []byte, tempDir string) ([]byte, bool, error) {
-	fmt.Printf("🔧 Converting with gif2webp (Google's official tool)...\n")
+func (bot *WhatsAppBot) convertWithGif2WebP(ctx context.Context, inputPath, tempDir string, attempt stickerAttempt) ([]byte, error) {
+	fmt.Printf("🔧 Converting with gif2webp (%s)...\n", attempt.Describe())
 
-	inputPath := filepath.Join(tempDir, "input.gif")
 	outputPath := filepath.Join(tempDir, "animated.webp")
-
-	// Save input GIF
-	err := ioutil.WriteFile(inputPath, gifData, 0644)
-	if err != nil {
-		return nil, false, fmt.Errorf("gagal save input GIF: %v", err)
-	}
+	size := strconv.Itoa(stickerSize)
 
 	// Use gif2webp with optimized settings for WhatsApp stickers
-	cmd := exec.Command("gif2webp",
-		"-q", "75", // Quality 75% (good balance)
-		"-m", "4", // Compression method 4 (good compression)
-		"-lossy",                // Use lossy compression
-		"-resize", "512", "512", // Resize to sticker dimensions
+	output, err := bot.runTool(ctx, "gif2webp",
+		"-q", strconv.Itoa(attempt.Quality),
+		"-m", "6", // Max compression method
+		"-lossy",              // Use lossy compression
+		"-resize", size, size, // Resize to sticker dimensions
 		"-mt", // Multi-threading
 		inputPath,
 		"-o", outputPath)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, false, fmt.Errorf("gif2webp failed: %v, output: %s", err, string(output))
+		return nil, fmt.Errorf("gif2webp failed: %v, output: %s", err, string(output))
 	}
 
-	// Check file size (WhatsApp has limits)
 	webpData, err := ioutil.ReadFile(outputPath)
 	if err != nil {
-		return nil, false, fmt.Errorf("gagal read WebP output: %v", err)
+		return nil, fmt.Errorf("gagal read WebP output: %v", err)
 	}
-
-	// WhatsApp sticker size limit is around 500KB
-	if len(webpData) > 500*1024 {
-		fmt.Printf("⚠️ File too large (%d bytes), trying compressed version...\n", len(webpData))
-		return bot.compressAnimatedWebP(inputPath, outputPath)
-	}
-
-	fmt.Printf("✅ Animated WebP sticker created (%d bytes)\n", len(webpData))
-	return webpData, true, nil
+	return webpData, nil
 }
 
-// compressAnimatedWebP - Compress animated WebP if too large
-func (bot *WhatsAppBot) compressAnimatedWebP(inputPath, outputPath string) ([]byte, bool, error) {
-	fmt.Printf("🗜️ Compressing animated WebP for WhatsApp size limit...\n")
+// encodeAnimatedStickerFFmpeg - One budget attempt with ffmpeg's libwebp, for GIF and video input
+func (bot *WhatsAppBot) encodeAnimatedStickerFFmpeg(ctx context.Context, inputPath, tempDir string, opts StickerOptions, attempt stickerAttempt) ([]byte, error) {
+	fmt.Printf("🔧 Converting with FFmpeg (animated WebP, %s)...\n", attempt.Describe())
 
-	// More aggressive compression
-	cmd := exec.Command("gif2webp",
-		"-q", "50", // Lower quality
-		"-m", "6", // Max compression method
-		"-lossy",
-		"-resize", "480", "480", // Smaller size
-		"-f", "15", // Lower frame rate
-		"-mt",
-		inputPath,
-		"-o", outputPath)
+	outputPath := filepath.Join(tempDir, "animated.webp")
 
-	output, err := cmd.CombinedOutput()
+	filterArgs, err := stickerFilterArgs(opts, attempt.FPS, attempt.Size, tempDir)
 	if err != nil {
-		return nil, false, fmt.Errorf("compression failed: %v, output: %s", err, string(output))
+		return nil, err
 	}
 
-	webpData, err := ioutil.ReadFile(outputPath)
-	if err != nil {
-		return nil, false, err
+	// Input seeking jumps straight to the clip start
+	var args []string
+	if opts.Start > 0 {
+		args = append(args, "-ss", formatClipTime(opts.Start))
 	}
-
-	if len(webpData) > 500*1024 {
-		fmt.Printf("⚠️ Still too large after compression (%d bytes), converting to static\n", len(webpData))
-		return nil, false, fmt.Errorf("file too large even after compression")
+	args = append(args, "-i", inputPath)
+	args = append(args, filterArgs...)
+	if attempt.Seconds > 0 {
+		args = append(args, "-t", strconv.FormatFloat(attempt.Seconds, 'f', 2, 64))
 	}
-
-	fmt.Printf("✅ Compressed animated WebP created (%d bytes)\n", len(webpData))
-	return webpData, true, nil
-}
-
-// convertWithFFmpegAnimated - Use FFmpeg for animated WebP (alternative method)
-func (bot *WhatsAppBot) convertWithFFmpegAnimated(gifData []byte, tempDir string) ([]byte, bool, error) {
-	fmt.Printf("🔧 Converting with FFmpeg (animated WebP)...\n")
-
-	inputPath := filepath.Join(tempDir, "input.gif")
-	outputPath := filepath.Join(tempDir, "animated.webp")
-
-	// Save input
-	err := ioutil.WriteFile(inputPath, gifData, 0644)
-	if err != nil {
-		return nil, false, err
-	}
-
-	// FFmpeg command for animated WebP
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
+	args = append(args,
 		"-vcodec", "libwebp",
-		"-filter:v", "fps=15,scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:-1:-1:color=white@0",
 		"-lossless", "0", // Lossy compression
-		"-quality", "75", // Quality setting
+		"-quality", strconv.Itoa(attempt.Quality),
+		"-compression_level", "6",
 		"-preset", "default", // Compression preset
 		"-loop", "0", // Infinite loop
 		"-an", // No audio
 		"-y",  // Overwrite output
 		outputPath)
-
-	output, err := cmd.CombinedOutput()
+	output, err := bot.runTool(ctx, "ffmpeg", args...)
 	if err != nil {
-		return nil, false, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+		return nil, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
 	}
 
-	webpData, err := ioutil.ReadFile(outputPath)
-	if err != nil {
-		return nil, false, err
-	}
-
-	// Check size limit
-	if len(webpData) > 500*1024 {
-		fmt.Printf("⚠️ FFmpeg WebP too large (%d bytes), needs compression\n", len(webpData))
-		return nil, false, fmt.Errorf("animated WebP too large")
-	}
-
-	fmt.Printf("✅ FFmpeg animated WebP created (%d bytes)\n", len(webpData))
-	return webpData, true, nil
+	return ioutil.ReadFile(outputPath)
 }
 
-// convertVideoToAnimatedStickerWebP - Convert video to animated sticker
-func (bot *WhatsAppBot) convertVideoToAnimatedStickerWebP(videoData []byte) ([]byte, bool, error) {
+// convertVideoToAnimatedStickerWebP - Convert video to animated sticker within the size budget;
+// also returns what had to be traded away to fit
+func (bot *WhatsAppBot) convertVideoToAnimatedStickerWebP(ctx context.Context, videoData []byte, opts StickerOptions) ([]byte, string, error) {
 	fmt.Printf("🎬 Converting video to animated sticker...\n")
 
 	if !bot.isToolAvailable("ffmpeg") {
-		return nil, false, fmt.Errorf("ffmpeg required for video to animated sticker")
+		return nil, "", fmt.Errorf("ffmpeg required for video to animated sticker")
 	}
 
 	tempDir, err := ioutil.TempDir("", "video_animated_sticker_*")
 	if err != nil {
-		return nil, false, err
+		return nil, "", err
 	}
 	defer os.RemoveAll(tempDir)
 
 	inputPath := filepath.Join(tempDir, "input.mp4")
-	outputPath := filepath.Join(tempDir, "animated.webp")
 
 	// Save input video
 	err = ioutil.WriteFile(inputPath, videoData, 0644)
 	if err != nil {
-		return nil, false, err
-	}
-
-	// Convert video to animated WebP with sticker optimization
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-t", "10", // Limit to 10 seconds max
-		"-vcodec", "libwebp",
-		"-filter:v", "fps=12,scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:-1:-1:color=white@0",
-		"-lossless", "0",
-		"-quality", "70",
-		"-preset", "default",
-		"-loop", "0",
-		"-an",
-		"-y",
-		outputPath)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, false, fmt.Errorf("video conversion failed: %v, output: %s", err, string(output))
+		return nil, "", err
 	}
 
-	webpData, err := ioutil.ReadFile(outputPath)
+	// 12 fps, the requested clip or the first 10 seconds to start with
+	ladder := stickerBudgetLadder(opts.QualityOr(70), 12, opts.ClipSeconds(maxAnimatedStickerSeconds))
+	webpData, attempt, err := bot.encodeWithinBudget(ctx, "Video", ladder,
+		func(ctx context.Context, attempt stickerAttempt) ([]byte, error) {
+			return bot.encodeAnimatedStickerFFmpeg(ctx, inputPath, tempDir, opts, attempt)
+		})
 	if err != nil {
-		return nil, false, err
+		return nil, "", err
 	}
-
-	// Size check
-	if len(webpData) > 500*1024 {
-		return nil, false, fmt.Errorf("animated video sticker too large (%d bytes)", len(webpData))
-	}
-
-	fmt.Printf("✅ Animated video sticker created (%d bytes)\n", len(webpData))
-	return webpData, true, nil
+	return webpData, attempt.Tradeoffs(ladder[0]), nil
 }
 
 // convertGifToStaticStickerWebP - Fallback: convert GIF to static sticker (first frame)
-func (bot *WhatsAppBot) convertGifToStaticStickerWebP(gifData []byte) ([]byte, error) {
+func (bot *WhatsAppBot) convertGifToStaticStickerWebP(ctx context.Context, gifData []byte, opts StickerOptions) ([]byte, error) {
 	fmt.Printf("📸 Converting GIF to static sticker (fallback)...\n")
 
 	// Decode GIF and extract first frame
@@ -443,37 +479,11 @@ func (bot *WhatsAppBot) convertGifToStaticStickerWebP(gifData []byte) ([]byte, e
 		return nil, fmt.Errorf("GIF tidak punya frame")
 	}
 
-	firstFrame := gifImg.Image[0]
-	stickerFrame := bot.resizeForSticker(firstFrame)
-
-	// Create temp for conversion
-	tempDir, err := ioutil.TempDir("", "gif_static_sticker_*")
-	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(tempDir)
-
-	tempPngPath := filepath.Join(tempDir, "frame.png")
-	outputPath := filepath.Join(tempDir, "sticker.webp")
-
-	// Save frame as PNG
-	file, err := os.Create(tempPngPath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	err = png.Encode(file, stickerFrame)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert to WebP
-	return bot.convertWithCWebPTool(tempPngPath, outputPath)
+	return bot.encodeStaticSticker(ctx, gifImg.Image[0], opts)
 }
 
 // convertVideoToStaticStickerWebP - Extract frame from video
-func (bot *WhatsAppBot) convertVideoToStaticStickerWebP(videoData []byte) ([]byte, error) {
+func (bot *WhatsAppBot) convertVideoToStaticStickerWebP(ctx context.Context, videoData []byte, opts StickerOptions) ([]byte, error) {
 	fmt.Printf("🎬 Converting video to static sticker (single frame)...\n")
 
 	if !bot.isToolAvailable("ffmpeg") {
@@ -488,7 +498,6 @@ func (bot *WhatsAppBot) convertVideoToStaticStickerWebP(videoData []byte) ([]byt
 
 	inputPath := filepath.Join(tempDir, "input.mp4")
 	framePath := filepath.Join(tempDir, "frame.png")
-	outputPath := filepath.Join(tempDir, "sticker.webp")
 
 	// Save input
 	err = ioutil.WriteFile(inputPath, videoData, 0644)
@@ -496,58 +505,57 @@ func (bot *WhatsAppBot) convertVideoToStaticStickerWebP(videoData []byte) ([]byt
 		return nil, err
 	}
 
-	// Extract single frame
-	cmd := exec.Command("ffmpeg",
+	// Extract single frame at the clip start, layout options are applied when encoding
+	var args []string
+	if opts.Start > 0 {
+		args = append(args, "-ss", formatClipTime(opts.Start))
+	}
+	args = append(args,
 		"-i", inputPath,
 		"-vframes", "1",
 		"-f", "image2",
-		"-vf", "scale=512:512:force_original_aspect_ratio=decrease,pad=512:512:-1:-1:color=white@0",
 		"-y",
 		framePath)
+	output, err := bot.runTool(ctx, "ffmpeg", args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("frame extraction failed: %v, output: %s", err, string(output))
+	}
 
-	err = cmd.Run()
+	frameData, err := ioutil.ReadFile(framePath)
 	if err != nil {
-		return nil, fmt.Errorf("frame extraction failed: %v", err)
+		return nil, fmt.Errorf("gagal read frame: %v", err)
+	}
+	frame, err := png.Decode(bytes.NewReader(frameData))
+	if err != nil {
+		return nil, fmt.Errorf("gagal decode frame: %v", err)
 	}
 
 	// Convert frame to WebP
-	return bot.convertWithCWebPTool(framePath, outputPath)
+	return bot.encodeStaticSticker(ctx, frame, opts)
 }
 
-// convertToStickerWebP - Convert image to WebP sticker using cwebp tool
-func (bot *WhatsAppBot) convertToStickerWebP(imageData []byte) ([]byte, error) {
+// convertToStickerWebP - Convert image to WebP sticker (native encoder, cwebp when configured)
+func (bot *WhatsAppBot) convertToStickerWebP(ctx context.Context, imageData []byte, opts StickerOptions) ([]byte, error) {
 	fmt.Printf("🔄 Converting to WebP sticker format...\n")
 
 	// Check if already WebP
-	if len(imageData) >= 12 &&
-		string(imageData[0:4]) == "RIFF" &&
-		string(imageData[8:12]) == "WEBP" {
+	if isWebP(imageData) {
 		fmt.Printf("✅ Already WebP format - optimizing for sticker...\n")
-		return bot.optimizeWebPSticker(imageData)
+		return bot.optimizeWebPSticker(ctx, imageData, opts)
 	}
 
-	// Create temp directory
-	tempDir, err := ioutil.TempDir("", "sticker_convert_*")
-	if err != nil {
-		return nil, fmt.Errorf("gagal create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	inputPath := filepath.Join(tempDir, "input")
-	outputPath := filepath.Join(tempDir, "sticker.webp")
-
 	// Detect format and decode
 	var img image.Image
+	var err error
 	reader := bytes.NewReader(imageData)
 
 	if len(imageData) >= 2 && imageData[0] == 0xFF && imageData[1] == 0xD8 {
 		fmt.Printf("📸 JPEG detected\n")
 		img, err = jpeg.Decode(reader)
-		inputPath += ".jpg"
 	} else if len(imageData) >= 8 && string(imageData[1:4]) == "PNG" {
 		fmt.Printf("🖼️ PNG detected\n")
 		img, err = png.Decode(reader)
-		inputPath += ".png"
 	} else {
 		return nil, fmt.Errorf("format ga didukung. cuma JPG/PNG aja")
 	}
@@ -556,72 +564,15 @@ func (bot *WhatsAppBot) convertToStickerWebP(imageData []byte) ([]byte, error) {
 		return nil, fmt.Errorf("gagal decode gambar: %v", err)
 	}
 
-	// Resize to proper sticker dimensions
-	stickerImg := bot.resizeForSticker(img)
-
-	// Save temporary PNG for conversion
-	tempPngPath := filepath.Join(tempDir, "temp.png")
-	file, err := os.Create(tempPngPath)
-	if err != nil {
-		return nil, fmt.Errorf("gagal create temp file: %v", err)
-	}
-
-	err = png.Encode(file, stickerImg)
-	file.Close()
-	if err != nil {
-		return nil, fmt.Errorf("gagal save temp PNG: %v", err)
-	}
-
-	// Convert to WebP using cwebp tool
-	webpData, err := bot.convertWithCWebPTool(tempPngPath, outputPath)
+	webpData, err := bot.encodeStaticSticker(ctx, img, opts)
 	if err != nil {
-		fmt.Printf("⚠️ cwebp failed, trying fallback methods...\n")
-
-		// Try ImageMagick as fallback
-		webpData, err = bot.convertWithImageMagickTool(tempPngPath, outputPath)
-		if err != nil {
-			fmt.Printf("⚠️ All WebP tools failed, using PNG fallback\n")
-			// Fallback to PNG (still works as sticker)
-			return bot.createPNGSticker(stickerImg)
-		}
+		return nil, err
 	}
 
 	fmt.Printf("✅ WebP sticker created (%d bytes)\n", len(webpData))
 	return webpData, nil
 }
 
-// resizeForSticker - Resize image to proper sticker dimensions (512x512 max, maintain aspect ratio)
-func (bot *WhatsAppBot) resizeForSticker(src image.Image) image.Image {
-	srcBounds := src.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
-
-	// Calculate dimensions maintaining aspect ratio
-	var newWidth, newHeight int
-	if srcWidth > srcHeight {
-		newWidth = 512
-		newHeight = int(float64(srcHeight) * 512.0 / float64(srcWidth))
-	} else {
-		newHeight = 512
-		newWidth = int(float64(srcWidth) * 512.0 / float64(srcHeight))
-	}
-
-	// Create new image with calculated dimensions
-	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
-
-	// Simple resize
-	for y := 0; y < newHeight; y++ {
-		for x := 0; x < newWidth; x++ {
-			srcX := int(float64(x) * float64(srcWidth) / float64(newWidth))
-			srcY := int(float64(y) * float64(srcHeight) / float64(newHeight))
-			dst.Set(x, y, src.At(srcBounds.Min.X+srcX, srcBounds.Min.Y+srcY))
-		}
-	}
-
-	fmt.Printf("✅ Resized to %dx%d (sticker dimensions)\n", newWidth, newHeight)
-	return dst
-}
-
 // isToolAvailable - Check if external tool is available
 func (bot *WhatsAppBot) isToolAvailable(toolName string) bool {
 	_, err := exec.LookPath(toolName)
@@ -629,7 +580,7 @@ func (bot *WhatsAppBot) isToolAvailable(toolName string) bool {
 }
 
 // convertWithCWebPTool - Convert using Google's cwebp command line tool
-func (bot *WhatsAppBot) convertWithCWebPTool(inputPath, outputPath string) ([]byte, error) {
+func (bot *WhatsAppBot) convertWithCWebPTool(ctx context.Context, inputPath, outputPath string, quality int) ([]byte, error) {
 	fmt.Printf("🔧 Converting with cwebp tool...\n")
 
 	// Check if cwebp is available
@@ -639,15 +590,13 @@ func (bot *WhatsAppBot) convertWithCWebPTool(inputPath, outputPath string) ([]by
 	}
 
 	// Run cwebp with sticker-optimized settings
-	cmd := exec.Command("cwebp",
-		"-q", "80", // Quality 80%
+	output, err := bot.runTool(ctx, "cwebp",
+		"-q", strconv.Itoa(quality), // Quality (80% by default)
 		"-preset", "picture", // Picture preset
 		"-resize", "512", "512", // Resize to 512x512
 		"-crop", "512", "512", "0", "0", // Crop if needed
 		inputPath,
 		"-o", outputPath)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("cwebp command failed: %v, output: %s", err, string(output))
 	}
@@ -668,7 +617,7 @@ func (bot *WhatsAppBot) convertWithCWebPTool(inputPath, outputPath string) ([]by
 }
 
 // convertWithImageMagickTool - Convert using ImageMagick convert
-func (bot *WhatsAppBot) convertWithImageMagickTool(inputPath, outputPath string) ([]byte, error) {
+func (bot *WhatsAppBot) convertWithImageMagickTool(ctx context.Context, inputPath, outputPath string, quality int) ([]byte, error) {
 	fmt.Printf("🔧 Converting with ImageMagick...\n")
 
 	// Check if convert is available
@@ -678,16 +627,14 @@ func (bot *WhatsAppBot) convertWithImageMagickTool(inputPath, outputPath string)
 	}
 
 	// Run convert with sticker settings
-	cmd := exec.Command("convert",
+	output, err := bot.runTool(ctx, "convert",
 		inputPath,
 		"-resize", "512x512>", // Resize maintaining aspect ratio, max 512x512
 		"-background", "transparent", // Transparent background
 		"-gravity", "center", // Center the image
 		"-extent", "512x512", // Extend canvas to exactly 512x512
-		"-quality", "80", // Set quality
+		"-quality", strconv.Itoa(quality), // Set quality
 		outputPath)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("imagemagick failed: %v, output: %s", err, string(output))
 	}
@@ -703,73 +650,37 @@ func (bot *WhatsAppBot) convertWithImageMagickTool(inputPath, outputPath string)
 }
 
 // optimizeWebPSticker - Optimize existing WebP for sticker use
-func (bot *WhatsAppBot) optimizeWebPSticker(webpData []byte) ([]byte, error) {
+func (bot *WhatsAppBot) optimizeWebPSticker(ctx context.Context, webpData []byte, opts StickerOptions) ([]byte, error) {
 	fmt.Printf("🔧 Optimizing existing WebP for sticker...\n")
 
-	tempDir, err := ioutil.TempDir("", "webp_optimize_*")
+	img, err := decodeWebPNative(webpData)
 	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(tempDir)
-
-	inputPath := filepath.Join(tempDir, "input.webp")
-	outputPath := filepath.Join(tempDir, "optimized.webp")
-
-	// Save input WebP
-	err = ioutil.WriteFile(inputPath, webpData, 0644)
-	if err != nil {
-		return nil, err
+		// Animated or unusual WebP - send it as it is
+		fmt.Printf("⚠️ WebP optimization skipped, using original: %v\n", err)
+		return webpData, nil
 	}
 
-	// Optimize with cwebp
-	cmd := exec.Command("cwebp",
-		inputPath,
-		"-q", "80",
-		"-resize", "512", "512",
-		"-o", outputPath)
-
-	err = cmd.Run()
+	optimizedData, err := bot.encodeStaticSticker(ctx, img, opts)
 	if err != nil {
-		// If optimization fails, return original
+		if errors.Is(err, errToolTimeout) || errors.Is(err, errMediaTooComplex) {
+			return nil, err
+		}
 		fmt.Printf("⚠️ WebP optimization failed, using original\n")
 		return webpData, nil
 	}
 
-	// Read optimized version
-	optimizedData, err := ioutil.ReadFile(outputPath)
-	if err != nil {
-		return webpData, nil // Return original on read error
-	}
-
 	fmt.Printf("✅ WebP optimized (%d -> %d bytes)\n", len(webpData), len(optimizedData))
 	return optimizedData, nil
 }
 
-// createPNGSticker - Create PNG sticker as fallback
-func (bot *WhatsAppBot) createPNGSticker(img image.Image) ([]byte, error) {
-	fmt.Printf("📦 Creating PNG sticker (fallback mode)...\n")
-
-	var buf bytes.Buffer
-	err := png.Encode(&buf, img)
-	if err != nil {
-		return nil, err
-	}
-
-	fmt.Printf("✅ PNG sticker ready (%d bytes)\n", buf.Len())
-	return buf.Bytes(), nil
-}
-
 // convertStickerToImageWebP - Convert sticker to image with WebP support
-func (bot *WhatsAppBot) convertStickerToImageWebP(stickerData []byte) ([]byte, error) {
+func (bot *WhatsAppBot) convertStickerToImageWebP(ctx context.Context, stickerData []byte) ([]byte, error) {
 	fmt.Printf("🔄 Converting sticker to image...\n")
 
 	// Check if it's WebP
-	if len(stickerData) >= 12 &&
-		string(stickerData[0:4]) == "RIFF" &&
-		string(stickerData[8:12]) == "WEBP" {
-
+	if isWebP(stickerData) {
 		fmt.Printf("🎯 WebP sticker detected - converting to PNG...\n")
-		return bot.webpToPNG(stickerData)
+		return bot.webpToPNG(ctx, stickerData)
 	}
 
 	// Handle other formats
@@ -804,8 +715,133 @@ func (bot *WhatsAppBot) convertStickerToImageWebP(stickerData []byte) ([]byte, e
 	return buf.Bytes(), nil
 }
 
-// webpToPNG - Convert WebP to PNG using dwebp tool
-func (bot *WhatsAppBot) webpToPNG(webpData []byte) ([]byte, error) {
+// convertAnimatedStickerToMP4 - Decode every frame in Go, flatten on white (MP4 has no alpha)
+// and let ffmpeg encode the PNG sequence with the original frame durations
+func (bot *WhatsAppBot) convertAnimatedStickerToMP4(ctx context.Context, stickerData []byte) ([]byte, uint32, error) {
+	if !bot.isToolAvailable("ffmpeg") {
+		return nil, 0, fmt.Errorf("ffmpeg diperlukan untuk stiker animasi")
+	}
+
+	frames, err := decodeAnimatedWebP(stickerData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tempDir, err := ioutil.TempDir("", "sticker_to_mp4_*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// concat demuxer list: each frame with its duration, the last one repeated so its duration counts
+	var list strings.Builder
+	var total time.Duration
+	var lastFrame string
+	for i, frame := range frames {
+		duration := frame.Duration
+		if duration < 20*time.Millisecond {
+			duration = 100 * time.Millisecond // same as browsers for 0/10ms frames
+		}
+		total += duration
+
+		framePath := filepath.Join(tempDir, fmt.Sprintf("frame_%04d.png", i))
+		if err := writeFlattenedPNG(framePath, frame.Image); err != nil {
+			return nil, 0, err
+		}
+		fmt.Fprintf(&list, "file '%s'\nduration %.3f\n", framePath, duration.Seconds())
+		lastFrame = framePath
+	}
+	fmt.Fprintf(&list, "file '%s'\n", lastFrame)
+
+	listPath := filepath.Join(tempDir, "frames.txt")
+	if err := ioutil.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return nil, 0, fmt.Errorf("gagal save frame list: %v", err)
+	}
+
+	outputPath := filepath.Join(tempDir, "output.mp4")
+	output, err := bot.runTool(ctx, "ffmpeg",
+		"-f", "concat", "-safe", "0",
+		"-i", listPath,
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2,format=yuv420p",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "23",
+		"-movflags", "+faststart",
+		"-an",
+		"-y", outputPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+	}
+
+	videoData, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal baca hasil video: %v", err)
+	}
+
+	seconds := uint32((total + time.Second - 1) / time.Second)
+	fmt.Printf("✅ Animated sticker converted to MP4 (%d frames, %ds, %d bytes)\n", len(frames), seconds, len(videoData))
+	return videoData, seconds, nil
+}
+
+// writeFlattenedPNG - Save a frame as PNG on a white background
+func writeFlattenedPNG(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, flattenImage(img)); err != nil {
+		return fmt.Errorf("gagal encode frame: %v", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("gagal save frame: %v", err)
+	}
+	return nil
+}
+
+// flattenImage - Draw over a white background, for formats without alpha
+func flattenImage(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return flat
+}
+
+// webpToPNG - Convert WebP to PNG, in-process by default with dwebp/ImageMagick as the other backend
+func (bot *WhatsAppBot) webpToPNG(ctx context.Context, webpData []byte) ([]byte, error) {
+	native := bot.webpBackend() == webpBackendNative
+	if native {
+		pngData, err := webpToPNGNative(webpData)
+		if err == nil {
+			return pngData, nil
+		}
+		fmt.Printf("⚠️ Native WebP decode failed, trying CLI tools: %v\n", err)
+	}
+
+	pngData, err := bot.webpToPNGCLI(ctx, webpData)
+	if err != nil && !native {
+		if nativeData, nativeErr := webpToPNGNative(webpData); nativeErr == nil {
+			return nativeData, nil
+		}
+	}
+	return pngData, err
+}
+
+// webpToPNGNative - Decode with x/image/webp and re-encode as PNG
+func webpToPNGNative(webpData []byte) ([]byte, error) {
+	img, err := decodeWebPNative(webpData)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("gagal encode PNG: %v", err)
+	}
+
+	fmt.Printf("✅ Native WebP decode successful\n")
+	return buf.Bytes(), nil
+}
+
+// webpToPNGCLI - Convert WebP to PNG using dwebp tool, ImageMagick as fallback
+func (bot *WhatsAppBot) webpToPNGCLI(ctx context.Context, webpData []byte) ([]byte, error) {
 	tempDir, err := ioutil.TempDir("", "webp_to_png_*")
 	if err != nil {
 		return nil, err
@@ -822,10 +858,10 @@ func (bot *WhatsAppBot) webpToPNG(webpData []byte) ([]byte, error) {
 	}
 
 	// Try dwebp first
-	err = bot.convertWebPWithDWebP(inputPath, outputPath)
+	err = bot.convertWebPWithDWebP(ctx, inputPath, outputPath)
 	if err != nil {
 		// Try ImageMagick as fallback
-		err = bot.convertWebPWithImageMagick(inputPath, outputPath)
+		err = bot.convertWebPWithImageMagick(ctx, inputPath, outputPath)
 		if err != nil {
 			return nil, fmt.Errorf("all WebP conversion tools failed: %v", err)
 		}
@@ -841,14 +877,13 @@ func (bot *WhatsAppBot) webpToPNG(webpData []byte) ([]byte, error) {
 }
 
 // convertWebPWithDWebP - Use dwebp tool
-func (bot *WhatsAppBot) convertWebPWithDWebP(inputPath, outputPath string) error {
+func (bot *WhatsAppBot) convertWebPWithDWebP(ctx context.Context, inputPath, outputPath string) error {
 	_, err := exec.LookPath("dwebp")
 	if err != nil {
 		return fmt.Errorf("dwebp not found")
 	}
 
-	cmd := exec.Command("dwebp", inputPath, "-o", outputPath)
-	output, err := cmd.CombinedOutput()
+	output, err := bot.runTool(ctx, "dwebp", inputPath, "-o", outputPath)
 	if err != nil {
 		return fmt.Errorf("dwebp failed: %v, output: %s", err, string(output))
 	}
@@ -858,14 +893,13 @@ func (bot *WhatsAppBot) convertWebPWithDWebP(inputPath, outputPath string) error
 }
 
 // convertWebPWithImageMagick - Use ImageMagick for WebP to PNG
-func (bot *WhatsAppBot) convertWebPWithImageMagick(inputPath, outputPath string) error {
+func (bot *WhatsAppBot) convertWebPWithImageMagick(ctx context.Context, inputPath, outputPath string) error {
 	_, err := exec.LookPath("convert")
 	if err != nil {
 		return fmt.Errorf("imagemagick not found")
 	}
 
-	cmd := exec.Command("convert", inputPath, outputPath)
-	output, err := cmd.CombinedOutput()
+	output, err := bot.runTool(ctx, "convert", inputPath, outputPath)
 	if err != nil {
 		return fmt.Errorf("imagemagick failed: %v, output: %s", err, string(output))
 	}
@@ -874,11 +908,37 @@ func (bot *WhatsAppBot) convertWebPWithImageMagick(inputPath, outputPath string)
 	return nil
 }
 
+// uploadMedia - Upload to WhatsApp, reusing an earlier upload of the same bytes when it's still fresh
+func (bot *WhatsAppBot) uploadMedia(data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	if uploaded, ok := bot.mediaCache.GetUpload(data, mediaType); ok {
+		return uploaded, nil
+	}
+
+	uploaded, err := bot.client.Upload(context.Background(), data, mediaType)
+	if err != nil {
+		return uploaded, err
+	}
+	bot.mediaCache.PutUpload(data, mediaType, uploaded)
+	return uploaded, nil
+}
+
+// sendStickerWithMetadata - Embed the pack name/author EXIF, then send the sticker
+func (bot *WhatsAppBot) sendStickerWithMetadata(chatJID types.JID, stickerData []byte, quotedMsgID string, isAnimated bool, meta StickerMetadata) error {
+	if isWebP(stickerData) {
+		if withMeta, err := addStickerMetadata(stickerData, meta); err != nil {
+			fmt.Printf("⚠️ Failed to add sticker metadata: %v\n", err)
+		} else {
+			stickerData = withMeta
+		}
+	}
+	return bot.sendSticker(chatJID, stickerData, quotedMsgID, isAnimated)
+}
+
 // sendSticker - Enhanced with animation support
 func (bot *WhatsAppBot) sendSticker(chatJID types.JID, stickerData []byte, quotedMsgID string, isAnimated bool) error {
 	fmt.Printf("📤 Uploading sticker (%d bytes, animated: %v)...\n", len(stickerData), isAnimated)
 
-	uploaded, err := bot.client.Upload(context.Background(), stickerData, whatsmeow.MediaImage)
+	uploaded, err := bot.uploadMedia(stickerData, whatsmeow.MediaImage)
 	if err != nil {
 		return fmt.Errorf("failed to upload sticker: %v", err)
 	}
@@ -937,9 +997,14 @@ func (bot *WhatsAppBot) sendSticker(chatJID types.JID, stickerData []byte, quote
 
 // sendImage - Send image to chat
 func (bot *WhatsAppBot) sendImage(chatJID types.JID, imageData []byte, filename string, quotedMsgID string) error {
+	return bot.sendImageWithCaption(chatJID, imageData, "udah ku jadiin gambar nih", quotedMsgID)
+}
+
+// sendImageWithCaption - Send image to chat with a custom caption
+func (bot *WhatsAppBot) sendImageWithCaption(chatJID types.JID, imageData []byte, caption string, quotedMsgID string) error {
 	fmt.Printf("📤 Uploading image (%d bytes)...\n", len(imageData))
 
-	uploaded, err := bot.client.Upload(context.Background(), imageData, whatsmeow.MediaImage)
+	uploaded, err := bot.uploadMedia(imageData, whatsmeow.MediaImage)
 	if err != nil {
 		return fmt.Errorf("failed to upload image: %v", err)
 	}
@@ -963,7 +1028,7 @@ func (bot *WhatsAppBot) sendImage(chatJID types.JID, imageData []byte, filename
 			FileEncSHA256: uploaded.FileEncSHA256,
 			FileSHA256:    uploaded.FileSHA256,
 			FileLength:    proto.Uint64(uint64(len(imageData))),
-			Caption:       proto.String("udah ku jadiin gambar nih"),
+			Caption:       proto.String(caption),
 			ContextInfo: &waProto.ContextInfo{
 				StanzaID: proto.String(quotedMsgID),
 			},
@@ -979,6 +1044,123 @@ func (bot *WhatsAppBot) sendImage(chatJID types.JID, imageData []byte, filename
 	return nil
 }
 
+// sendVideo - Send MP4 video to chat; gifPlayback makes WhatsApp loop it like a GIF
+func (bot *WhatsAppBot) sendVideo(chatJID types.JID, videoData []byte, seconds uint32, gifPlayback bool, quotedMsgID string) error {
+	fmt.Printf("📤 Uploading video (%d bytes, gif: %v)...\n", len(videoData), gifPlayback)
+
+	uploaded, err := bot.uploadMedia(videoData, whatsmeow.MediaVideo)
+	if err != nil {
+		return fmt.Errorf("failed to upload video: %v", err)
+	}
+
+	caption := "udah ku jadiin video nih"
+	if gifPlayback {
+		caption = "udah ku jadiin gif nih"
+	}
+
+	videoMsg := &waProto.Message{
+		VideoMessage: &waProto.VideoMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String("video/mp4"),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(videoData))),
+			Seconds:       proto.Uint32(seconds),
+			GifPlayback:   proto.Bool(gifPlayback),
+			Caption:       proto.String(caption),
+			ContextInfo: &waProto.ContextInfo{
+				StanzaID: proto.String(quotedMsgID),
+			},
+		},
+	}
+
+	_, err = bot.client.SendMessage(context.Background(), chatJID, videoMsg)
+	if err != nil {
+		return fmt.Errorf("failed to send video message: %v", err)
+	}
+
+	fmt.Printf("✅ Video sent successfully\n")
+	return nil
+}
+
+// sendAudio - Send audio to chat; ptt sends it as a voice note with the given waveform
+func (bot *WhatsAppBot) sendAudio(chatJID types.JID, audioData []byte, seconds uint32, ptt bool, waveform []byte, quotedMsgID string) error {
+	fmt.Printf("📤 Uploading audio (%d bytes, voice note: %v)...\n", len(audioData), ptt)
+
+	uploaded, err := bot.uploadMedia(audioData, whatsmeow.MediaAudio)
+	if err != nil {
+		return fmt.Errorf("failed to upload audio: %v", err)
+	}
+
+	mimetype := "audio/mpeg"
+	if ptt {
+		mimetype = "audio/ogg; codecs=opus"
+	}
+
+	audioMsg := &waProto.Message{
+		AudioMessage: &waProto.AudioMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimetype),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(audioData))),
+			Seconds:       proto.Uint32(seconds),
+			PTT:           proto.Bool(ptt),
+			Waveform:      waveform,
+			ContextInfo: &waProto.ContextInfo{
+				StanzaID: proto.String(quotedMsgID),
+			},
+		},
+	}
+
+	_, err = bot.client.SendMessage(context.Background(), chatJID, audioMsg)
+	if err != nil {
+		return fmt.Errorf("failed to send audio message: %v", err)
+	}
+
+	fmt.Printf("✅ Audio sent successfully\n")
+	return nil
+}
+
+// sendDocument - Send any file as a document, so WhatsApp doesn't recompress it
+func (bot *WhatsAppBot) sendDocument(chatJID types.JID, data []byte, mimetype, filename, quotedMsgID string) error {
+	fmt.Printf("📤 Uploading document %s (%d bytes)...\n", filename, len(data))
+
+	uploaded, err := bot.uploadMedia(data, whatsmeow.MediaDocument)
+	if err != nil {
+		return fmt.Errorf("failed to upload document: %v", err)
+	}
+
+	documentMsg := &waProto.Message{
+		DocumentMessage: &waProto.DocumentMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimetype),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(data))),
+			FileName:      proto.String(filename),
+			Title:         proto.String(filename),
+			ContextInfo: &waProto.ContextInfo{
+				StanzaID: proto.String(quotedMsgID),
+			},
+		},
+	}
+
+	_, err = bot.client.SendMessage(context.Background(), chatJID, documentMsg)
+	if err != nil {
+		return fmt.Errorf("failed to send document message: %v", err)
+	}
+
+	fmt.Printf("✅ Document sent successfully\n")
+	return nil
+}
+
 // TagAllHandler - Handle tag all with corrected reply functionality and message format
 func (bot *WhatsAppBot) TagAllHandler(chatJID types.JID, quotedMsgID string, quotedText string) string {
 	fmt.Printf("👥 PROCESSING: Tag all members in group %s\n", chatJID.User)
@@ -1050,6 +1232,7 @@ func (bot *WhatsAppBot) TagAllHandler(chatJID types.JID, quotedMsgID string, quo
 // checkWebPToolsAvailability - Enhanced with animation tools check
 func (bot *WhatsAppBot) checkWebPToolsAvailability() {
 	fmt.Printf("🔍 Checking WebP and media tools availability...\n")
+	fmt.Printf("🧩 Static WebP backend: %s\n", bot.webpBackendLabel())
 
 	// Check cwebp
 	if _, err := exec.LookPath("cwebp"); err == nil {
@@ -1080,24 +1263,41 @@ func (bot *WhatsAppBot) checkWebPToolsAvailability() {
 	}
 
 	// Check FFmpeg for GIF/video processing
+	bot.probeFFmpegEncoders()
 	if _, err := exec.LookPath("ffmpeg"); err == nil {
 		fmt.Printf("✅ FFmpeg found (GIF/video support enabled)\n")
 		// Check libwebp support
-		cmd := exec.Command("ffmpeg", "-codecs")
-		output, err := cmd.CombinedOutput()
-		if err == nil && strings.Contains(string(output), "libwebp") {
+		if bot.ffmpegHasLibWebP() {
 			fmt.Printf("✅ FFmpeg with libwebp support detected\n")
 		} else {
 			fmt.Printf("⚠️ FFmpeg found but libwebp support unclear\n")
 		}
+		// Audio encoders for .tomp3 / .tovn
+		if bot.ffmpegHasEncoder("libmp3lame") {
+			fmt.Printf("✅ FFmpeg with libmp3lame detected (.tomp3 enabled)\n")
+		} else {
+			fmt.Printf("⚠️ FFmpeg without libmp3lame (.tomp3 disabled)\n")
+		}
+		if bot.ffmpegHasEncoder("libopus") {
+			fmt.Printf("✅ FFmpeg with libopus detected (.tovn enabled)\n")
+		} else {
+			fmt.Printf("⚠️ FFmpeg without libopus (.tovn uses native Opus encoder)\n")
+		}
 	} else {
 		fmt.Printf("❌ FFmpeg not found (GIF/video processing limited)\n")
 	}
 
+	// Check tesseract for .ocr
+	if ok, detail := bot.ocrStatus(); ok {
+		fmt.Printf("✅ OCR available: %s\n", detail)
+	} else {
+		fmt.Printf("⚠️ OCR unavailable: %s (.ocr disabled)\n", detail)
+	}
+
 	// Installation instructions
 	fmt.Printf("\n💡 To install media tools:\n")
-	fmt.Printf("Ubuntu/Debian: sudo apt-get install webp imagemagick ffmpeg\n")
-	fmt.Printf("macOS: brew install webp imagemagick ffmpeg\n")
+	fmt.Printf("Ubuntu/Debian: sudo apt-get install webp imagemagick ffmpeg tesseract-ocr tesseract-ocr-ind\n")
+	fmt.Printf("macOS: brew install webp imagemagick ffmpeg tesseract tesseract-lang\n")
 	fmt.Printf("Windows: Download from respective official sites\n")
 	fmt.Printf("\n🎞️ For BEST animated sticker support:\n")
 	fmt.Printf("- gif2webp (part of webp package) - ESSENTIAL\n")
@@ -0,0 +1,179 @@
+// help.go - .hi / .help menus generated from registered command metadata
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isCommandVisible - Whether a command should be listed for this chat and caller
+func (bot *WhatsAppBot) isCommandVisible(ctx *CommandContext, cmd Command) bool {
+	if cmd.GroupOnly() && !ctx.IsGroup {
+		return false
+	}
+	if !ctx.Policy.IsCommandEnabled(cmd.Name()) {
+		return false
+	}
+	return ctx.HasRole(cmd.MinRole())
+}
+
+// visibleCommands - Registered commands the caller can use in this chat
+func (bot *WhatsAppBot) visibleCommands(ctx *CommandContext) []Command {
+	var visible []Command
+	for _, cmd := range bot.commands.Commands() {
+		if bot.isCommandVisible(ctx, cmd) {
+			visible = append(visible, cmd)
+		}
+	}
+	return visible
+}
+
+// commandTitle - ".sticker atau .s" style label for menus
+func commandTitle(cmd Command, prefix string) string {
+	names := []string{prefix + cmd.Name()}
+	for _, alias := range cmd.Aliases() {
+		names = append(names, prefix+alias)
+	}
+	return strings.Join(names, " atau ")
+}
+
+// legacyCommandList - "/help, /sticker, /s" list of commands still reachable via "/"
+func (bot *WhatsAppBot) legacyCommandList() string {
+	var names []string
+	for _, cmd := range bot.commands.Commands() {
+		if !cmd.Legacy() {
+			continue
+		}
+		names = append(names, "/"+cmd.Name())
+		for _, alias := range cmd.Aliases() {
+			names = append(names, "/"+alias)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// commandLines - Menu lines for every visible command using the given bullet
+func (bot *WhatsAppBot) commandLines(ctx *CommandContext, bullet string) string {
+	var sb strings.Builder
+	for _, cmd := range bot.visibleCommands(ctx) {
+		sb.WriteString(fmt.Sprintf("%s%s - %s\n", bullet, commandTitle(cmd, ctx.Policy.PrimaryPrefix()), cmd.Description()))
+	}
+	return sb.String()
+}
+
+func hiCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	policy := ctx.Policy
+	lines := bot.commandLines(ctx, "")
+
+	if policy.Greeting != "" {
+		return policy.Greeting + "\n\n" +
+			"Commands:\n" + lines +
+			"\nbot siap melayani! 🤖"
+	}
+
+	if policy.Exclusive {
+		return fmt.Sprintf("halo %s! 💎\n\n", policy.Name) +
+			"Commands untuk grup eksklusif ini:\n" + lines +
+			fmt.Sprintf("\nspecial bot untuk %s only! 🤖✨", policy.Name)
+	} else if ctx.IsGroup {
+		return "halo grup! 👋\n\n" +
+			"Commands utama:\n" + lines +
+			"\nbot siap melayani grup ini! 🤖"
+	}
+
+	return "halo! 👋\n\n" +
+		"Commands utama:\n" + lines +
+		"\nbot siap melayani! 🤖"
+}
+
+func helpCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	policy := ctx.Policy
+	prefix := policy.PrimaryPrefix()
+
+	// .help <command> - detailed usage for a single command
+	if len(ctx.Args) > 0 {
+		name := strings.ToLower(ctx.Args[0])
+		if matched := policy.MatchPrefix(name); matched != "" {
+			name = strings.TrimPrefix(name, matched)
+		}
+		cmd, ok := bot.commands.Lookup(name)
+		if !ok || !bot.isCommandVisible(ctx, cmd) {
+			return fmt.Sprintf("command %s%s ga ada. coba %shelp buat liat semua command", prefix, name, prefix)
+		}
+		return bot.commandHelp(cmd, prefix)
+	}
+
+	lines := bot.commandLines(ctx, "• ")
+	features := `🎞️ Fitur Animated Sticker:
+• GIF → Animated WebP sticker (bergerak!)
+• Video → Animated WebP sticker
+• Image → Static WebP sticker
+• Auto-resize ke 512x512
+• Support gif2webp & FFmpeg
+`
+
+	// The "/" legacy note only makes sense where "/" is still accepted
+	legacyNote := "\n"
+	if policy.AcceptsPrefix("/") && prefix != "/" {
+		legacyNote = "💡 Note: Beberapa perintah lama masih tersedia:\n" + bot.legacyCommandList() + "\n\n"
+	}
+
+	if ctx.Prefix == "/" && prefix != "/" {
+		return "🤖 WhatsApp Bot Helper - Legacy Help\n\n" +
+			fmt.Sprintf("perintah utama sudah pindah ke '%s' commands!\n\n", prefix) +
+			"📋 Commands baru:\n" + lines + "\n" +
+			features + "\n" +
+			"💡 Perintah legacy yang masih tersedia:\n" + bot.legacyCommandList() + "\n\n" +
+			fmt.Sprintf("gunakan %shelp untuk bantuan lengkap! 🎞️✨", prefix)
+	}
+
+	if policy.Exclusive {
+		return fmt.Sprintf("🤖 WhatsApp Bot Helper - %s 💎\n\n", policy.Name) +
+			fmt.Sprintf("aku bot eksklusif untuk grup %s!\n\n", policy.Name) +
+			"📋 Commands:\n" + lines + "\n" +
+			features + "\n" +
+			fmt.Sprintf("💡 ketik %shelp <command> buat detail\n\n", prefix) +
+			fmt.Sprintf("special untuk %s only! 💎✨", policy.Name)
+	}
+
+	return "🤖 WhatsApp Bot Helper - Animated Sticker Edition\n\n" +
+		"aku bot yang bisa convert sticker dengan WebP + animasi!\n\n" +
+		"📋 Commands:\n" + lines + "\n" +
+		features + "\n" +
+		fmt.Sprintf("💡 ketik %shelp <command> buat detail\n", prefix) +
+		legacyNote +
+		"animated stickers ftw! 🎞️✨"
+}
+
+// commandHelp - Detailed help for one command
+func (bot *WhatsAppBot) commandHelp(cmd Command, prefix string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("📖 *Bantuan %s%s*\n\n", prefix, cmd.Name()))
+	sb.WriteString(cmd.Description() + "\n\n")
+	sb.WriteString(fmt.Sprintf("🧾 cara pakai: %s\n", cmd.Usage()))
+
+	if len(cmd.Aliases()) > 0 {
+		sb.WriteString(fmt.Sprintf("🔁 alias: %s\n", commandTitle(cmd, prefix)))
+	}
+	if cmd.GroupOnly() {
+		sb.WriteString("👥 cuma bisa dipake di grup\n")
+	}
+	if cmd.MinRole() > RoleMember {
+		sb.WriteString(fmt.Sprintf("🔐 khusus %s\n", cmd.MinRole().Label()))
+	}
+	if cmd.Legacy() && prefix != "/" {
+		sb.WriteString(fmt.Sprintf("💡 bisa juga pake /%s\n", cmd.Name()))
+	}
+
+	if examples := cmd.Examples(); len(examples) > 0 {
+		sb.WriteString("\n✨ contoh:\n")
+		for _, example := range examples {
+			sb.WriteString("• " + example + "\n")
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
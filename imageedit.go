@@ -0,0 +1,243 @@
+// imageedit.go - .edit filter chains on images and stickers
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	xdraw "golang.org/x/image/draw"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "edit",
+		CmdDescription: "edit gambar/stiker pake filter (bisa digabung)",
+		CmdUsage:       ".edit <filter,filter=angka,...> (reply gambar atau stiker)",
+		CmdExamples:    []string{".edit gray", ".edit blur=5", ".edit gray,blur=3,rotate=90", ".edit flip=v,invert", ".edit pixelate=16"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        editCommand,
+	})
+}
+
+// editMaxSide - Big photos are scaled down first so filters stay quick
+const editMaxSide = 1600
+
+// animatedBudgetStep - Posterize bits and frame decimation tried when an animation is too big
+type animatedBudgetStep struct {
+	bits  uint
+	every int
+}
+
+var animatedBudgetSteps = []animatedBudgetStep{{0, 1}, {2, 1}, {4, 1}, {4, 2}, {4, 4}}
+
+func editCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	filters, err := parseImageFilters(ctx.RawArgs())
+	if err != nil {
+		return fmt.Sprintf("%s\nfilter: %s\ncontoh: %sedit gray,blur=3,rotate=90", err.Error(), imageFilterList(), prefix)
+	}
+
+	isSticker := bot.hasQuotedSticker(ctx.Message)
+	if !isSticker && bot.quotedImageMessage(ctx.Message) == nil {
+		return "reply gambar atau stiker dulu biar bisa diedit"
+	}
+
+	priority := mediaPriorityImage
+	stickerMsg := ctx.Message.Message.GetStickerMessage()
+	if stickerMsg == nil {
+		stickerMsg = ctx.Message.Message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage().GetStickerMessage()
+	}
+	if stickerMsg.GetIsAnimated() {
+		priority = mediaPriorityGIF
+	}
+
+	meta := bot.stickerMetadataFor(ctx.Sender.User)
+	return bot.queueMediaCommand(ctx, priority, func(jobCtx context.Context) string {
+		return bot.EditImageHandler(jobCtx, ctx.Sender, ctx.Message, filters, isSticker, meta)
+	})
+}
+
+// EditImageHandler - Apply a filter chain; images come back as images quoting the original,
+// stickers come back as stickers (animated ones frame by frame)
+func (bot *WhatsAppBot) EditImageHandler(ctx context.Context, sender types.JID, msg *events.Message, filters []imageFilter, isSticker bool, meta StickerMetadata) string {
+	chain := describeImageFilters(filters)
+	fmt.Printf("🪄 PROCESSING: Editing image for +%s (%s)\n", sender.User, chain)
+
+	cacheKey := mediaCacheKey(cacheKindEdit, bot.mediaFileSHA256(msg), chain)
+	if cached, ok := bot.mediaCache.Get(cacheKey); ok {
+		return bot.sendEditedMedia(msg, sender, cached, meta)
+	}
+
+	var edited CachedMedia
+	var err error
+	if isSticker {
+		edited, err = bot.editSticker(ctx, msg, filters)
+	} else {
+		edited, err = bot.editImage(ctx, msg, filters)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to edit image: %v\n", err)
+		return "waduh gagal edit gambarnya: " + err.Error()
+	}
+	if ctx.Err() != nil {
+		return "waduh gagal edit gambarnya: " + ctx.Err().Error()
+	}
+
+	bot.mediaCache.Put(cacheKey, cacheKindEdit, edited)
+	return bot.sendEditedMedia(msg, sender, edited, meta)
+}
+
+// editImage - Decode a JPEG/PNG/GIF/WebP photo, filter it and re-encode it
+func (bot *WhatsAppBot) editImage(ctx context.Context, msg *events.Message, filters []imageFilter) (CachedMedia, error) {
+	imageData, _, err := bot.downloadMedia(ctx, msg)
+	if err != nil {
+		return CachedMedia{}, fmt.Errorf("gagal download gambar: %v", err)
+	}
+
+	img, format, err := decodeStillImage(imageData)
+	if err != nil {
+		return CachedMedia{}, fmt.Errorf("format gambar ga didukung: %v", err)
+	}
+
+	edited := applyImageFilters(limitImageSide(img, editMaxSide), filters)
+	output, err := encodeLikeSource(edited, format)
+	if err != nil {
+		return CachedMedia{}, err
+	}
+
+	fmt.Printf("✅ Image edited (%dx%d, %d bytes)\n", edited.Rect.Dx(), edited.Rect.Dy(), len(output))
+	return CachedMedia{Data: output}, nil
+}
+
+// editSticker - Filter a static sticker, or every frame of an animated one
+func (bot *WhatsAppBot) editSticker(ctx context.Context, msg *events.Message, filters []imageFilter) (CachedMedia, error) {
+	stickerData, err := bot.downloadSticker(ctx, msg)
+	if err != nil {
+		return CachedMedia{}, fmt.Errorf("gagal download stiker: %v", err)
+	}
+
+	if isAnimatedWebP(stickerData) {
+		frames, err := decodeAnimatedWebP(stickerData)
+		if err != nil {
+			return CachedMedia{}, err
+		}
+		for i := range frames {
+			if ctx.Err() != nil {
+				return CachedMedia{}, ctx.Err()
+			}
+			frames[i].Image = applyImageFilters(frames[i].Image, filters)
+		}
+
+		webpData, err := bot.encodeAnimationInBudget(ctx, frames)
+		if err != nil {
+			return CachedMedia{}, err
+		}
+		return CachedMedia{Data: webpData, Animated: true}, nil
+	}
+
+	img, _, err := decodeStillImage(stickerData)
+	if err != nil {
+		return CachedMedia{}, fmt.Errorf("gagal decode stiker: %v", err)
+	}
+
+	webpData, err := bot.encodeStaticSticker(ctx, applyImageFilters(img, filters), StickerOptions{})
+	if err != nil {
+		return CachedMedia{}, err
+	}
+	return CachedMedia{Data: webpData}, nil
+}
+
+// encodeAnimationInBudget - Lossless animated encode; fewer colors, then fewer frames until it
+// fits the animated sticker cap (.edit and .attp)
+func (bot *WhatsAppBot) encodeAnimationInBudget(ctx context.Context, frames []webpFrame) ([]byte, error) {
+	budget := bot.config.Media.AnimatedStickerMaxBytes()
+
+	for i, step := range animatedBudgetSteps {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var candidate []webpFrame
+		for j := 0; j < len(frames); j += step.every {
+			frame := frames[j]
+			for k := j + 1; k < j+step.every && k < len(frames); k++ {
+				frame.Duration += frames[k].Duration // keep the original timing
+			}
+			if step.bits > 0 {
+				frame.Image = posterize(frame.Image, step.bits)
+			}
+			candidate = append(candidate, frame)
+		}
+
+		webpData, err := encodeAnimatedWebPNative(candidate, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(webpData) <= budget {
+			fmt.Printf("✅ Animation fits on attempt %d/%d: %d KB of %d KB\n",
+				i+1, len(animatedBudgetSteps), len(webpData)/1024, budget/1024)
+			return webpData, nil
+		}
+		fmt.Printf("⚠️ Animation attempt %d/%d too large: %d KB of %d KB\n",
+			i+1, len(animatedBudgetSteps), len(webpData)/1024, budget/1024)
+	}
+
+	return nil, fmt.Errorf("stiker animasinya tetap di atas %d KB", budget/1024)
+}
+
+// sendEditedMedia - WebP results go back as stickers, anything else as an image quoting the original
+func (bot *WhatsAppBot) sendEditedMedia(msg *events.Message, sender types.JID, edited CachedMedia, meta StickerMetadata) string {
+	quotedID := originalMediaMessageID(msg)
+
+	if isWebP(edited.Data) {
+		err := bot.sendStickerWithMetadata(msg.Info.Chat, edited.Data, quotedID, edited.Animated, meta)
+		if err != nil {
+			fmt.Printf("❌ Failed to send sticker: %v\n", err)
+			return "yah gagal kirim stickernya. coba lagi deh"
+		}
+		fmt.Printf("✅ Edited sticker sent successfully to +%s\n", sender.User)
+		return ""
+	}
+
+	err := bot.sendImage(msg.Info.Chat, edited.Data, "edited_image.png", quotedID)
+	if err != nil {
+		fmt.Printf("❌ Failed to send image: %v\n", err)
+		return "yah gagal kirim gambarnya. coba lagi deh"
+	}
+	fmt.Printf("✅ Edited image sent successfully to +%s\n", sender.User)
+	return ""
+}
+
+// originalMediaMessageID - ID of the replied-to media, or of the message itself when the
+// command was its caption
+func originalMediaMessageID(msg *events.Message) string {
+	if id := msg.Message.GetExtendedTextMessage().GetContextInfo().GetStanzaID(); id != "" {
+		return id
+	}
+	return msg.Info.ID
+}
+
+// limitImageSide - Scale down so the longer side is at most maxSide
+func limitImageSide(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+
+	if w > h {
+		w, h = maxSide, max(1, h*maxSide/w)
+	} else {
+		w, h = max(1, w*maxSide/h), maxSide
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(out, out.Bounds(), img, bounds, xdraw.Src, nil)
+	fmt.Printf("📐 Scaled %dx%d down to %dx%d before editing\n", bounds.Dx(), bounds.Dy(), w, h)
+	return out
+}
@@ -0,0 +1,358 @@
+// imagefilters.go - Pure Go image filters for .edit and parsing of filter chains
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxImageFilters - Longest .edit chain accepted
+const maxImageFilters = 8
+
+// imageFilter - One parsed step of an .edit chain
+type imageFilter struct {
+	Name   string
+	Amount int
+}
+
+// imageFilterSpec - A filter users can ask for
+type imageFilterSpec struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	Parse   func(value string, hasValue bool) (int, error)
+	Apply   func(img *image.NRGBA, amount int) *image.NRGBA
+}
+
+// imageFilterSpecs - Available filters, in the order shown to users
+var imageFilterSpecs = []imageFilterSpec{
+	{Name: "gray", Aliases: []string{"grayscale", "bw"}, Usage: "gray", Parse: noFilterValue, Apply: grayscaleImage},
+	{Name: "blur", Usage: "blur=1-20", Parse: filterRange(3, 1, 20), Apply: blurImage},
+	{Name: "invert", Aliases: []string{"negative"}, Usage: "invert", Parse: noFilterValue, Apply: invertImage},
+	{Name: "rotate", Usage: "rotate=90|180|270", Parse: parseRotateValue, Apply: rotateImage},
+	{Name: "flip", Aliases: []string{"mirror"}, Usage: "flip[=h|v]", Parse: parseFlipValue, Apply: flipImage},
+	{Name: "pixelate", Aliases: []string{"pixel"}, Usage: "pixelate=2-64", Parse: filterRange(12, 2, 64), Apply: pixelateImage},
+	{Name: "sharpen", Usage: "sharpen=1-5", Parse: filterRange(1, 1, 5), Apply: sharpenImage},
+}
+
+// Flip directions
+const (
+	flipHorizontal = 0
+	flipVertical   = 1
+)
+
+// findImageFilter - Spec for a filter name or alias
+func findImageFilter(name string) *imageFilterSpec {
+	for i := range imageFilterSpecs {
+		spec := &imageFilterSpecs[i]
+		if spec.Name == name {
+			return spec
+		}
+		for _, alias := range spec.Aliases {
+			if alias == name {
+				return spec
+			}
+		}
+	}
+	return nil
+}
+
+// imageFilterList - Filter usages for help and error replies
+func imageFilterList() string {
+	usages := make([]string, 0, len(imageFilterSpecs))
+	for _, spec := range imageFilterSpecs {
+		usages = append(usages, spec.Usage)
+	}
+	return strings.Join(usages, ", ")
+}
+
+// parseImageFilters - "gray,blur=3,rotate=90" (commas or spaces) into filter steps
+func parseImageFilters(text string) ([]imageFilter, error) {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("filternya belum diisi")
+	}
+	if len(fields) > maxImageFilters {
+		return nil, fmt.Errorf("kebanyakan filter, maksimal %d sekaligus", maxImageFilters)
+	}
+
+	filters := make([]imageFilter, 0, len(fields))
+	for _, field := range fields {
+		name, value, hasValue := strings.Cut(field, "=")
+		spec := findImageFilter(name)
+		if spec == nil {
+			return nil, fmt.Errorf("filter %q ga dikenal", name)
+		}
+		amount, err := spec.Parse(value, hasValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", spec.Name, err)
+		}
+		filters = append(filters, imageFilter{Name: spec.Name, Amount: amount})
+	}
+	return filters, nil
+}
+
+// describeImageFilters - Canonical chain, used for logs and cache keys
+func describeImageFilters(filters []imageFilter) string {
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		parts = append(parts, fmt.Sprintf("%s=%d", filter.Name, filter.Amount))
+	}
+	return strings.Join(parts, ",")
+}
+
+// applyImageFilters - Run the chain in order on a copy of img
+func applyImageFilters(img image.Image, filters []imageFilter) *image.NRGBA {
+	out := toNRGBA(img)
+	for _, filter := range filters {
+		out = findImageFilter(filter.Name).Apply(out, filter.Amount)
+	}
+	return out
+}
+
+func noFilterValue(value string, hasValue bool) (int, error) {
+	if hasValue {
+		return 0, fmt.Errorf("ga pake angka")
+	}
+	return 0, nil
+}
+
+// filterRange - Parser for an optional integer amount within [lo, hi]
+func filterRange(def, lo, hi int) func(string, bool) (int, error) {
+	return func(value string, hasValue bool) (int, error) {
+		if !hasValue {
+			return def, nil
+		}
+		amount, err := strconv.Atoi(value)
+		if err != nil || amount < lo || amount > hi {
+			return 0, fmt.Errorf("angkanya harus %d-%d", lo, hi)
+		}
+		return amount, nil
+	}
+}
+
+// parseRotateValue - Clockwise degrees in steps of 90, negative turns counter-clockwise
+func parseRotateValue(value string, hasValue bool) (int, error) {
+	if !hasValue {
+		return 90, nil
+	}
+	degrees, err := strconv.Atoi(strings.TrimSuffix(value, "°"))
+	if err != nil || degrees%90 != 0 {
+		return 0, fmt.Errorf("cuma bisa kelipatan 90 derajat")
+	}
+	return ((degrees % 360) + 360) % 360, nil
+}
+
+func parseFlipValue(value string, hasValue bool) (int, error) {
+	switch {
+	case !hasValue, value == "h", value == "horizontal":
+		return flipHorizontal, nil
+	case value == "v", value == "vertical":
+		return flipVertical, nil
+	}
+	return 0, fmt.Errorf("pilih h atau v")
+}
+
+// toNRGBA - Copy into a fresh NRGBA anchored at 0,0
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+	return out
+}
+
+// grayscaleImage - Rec. 601 luma, alpha kept
+func grayscaleImage(img *image.NRGBA, _ int) *image.NRGBA {
+	out := image.NewNRGBA(img.Rect)
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+		luma := uint8((299*r + 587*g + 114*b + 500) / 1000)
+		out.Pix[i], out.Pix[i+1], out.Pix[i+2], out.Pix[i+3] = luma, luma, luma, img.Pix[i+3]
+	}
+	return out
+}
+
+// invertImage - Negative colors, alpha kept
+func invertImage(img *image.NRGBA, _ int) *image.NRGBA {
+	out := image.NewNRGBA(img.Rect)
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		out.Pix[i] = 255 - img.Pix[i]
+		out.Pix[i+1] = 255 - img.Pix[i+1]
+		out.Pix[i+2] = 255 - img.Pix[i+2]
+		out.Pix[i+3] = img.Pix[i+3]
+	}
+	return out
+}
+
+// rotateImage - Rotate clockwise by 0/90/180/270 degrees
+func rotateImage(img *image.NRGBA, degrees int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if degrees == 0 {
+		return img
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, h, w))
+	if degrees == 180 {
+		out = image.NewNRGBA(image.Rect(0, 0, w, h))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch degrees {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			default: // 270
+				dx, dy = y, w-1-x
+			}
+			copy(out.Pix[out.PixOffset(dx, dy):out.PixOffset(dx, dy)+4], img.Pix[img.PixOffset(x, y):img.PixOffset(x, y)+4])
+		}
+	}
+	return out
+}
+
+// flipImage - Mirror left-right (flipHorizontal) or upside down (flipVertical)
+func flipImage(img *image.NRGBA, direction int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewNRGBA(img.Rect)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := w-1-x, y
+			if direction == flipVertical {
+				dx, dy = x, h-1-y
+			}
+			copy(out.Pix[out.PixOffset(dx, dy):out.PixOffset(dx, dy)+4], img.Pix[img.PixOffset(x, y):img.PixOffset(x, y)+4])
+		}
+	}
+	return out
+}
+
+// pixelateImage - Replace each block with its alpha-weighted average color
+func pixelateImage(img *image.NRGBA, block int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewNRGBA(img.Rect)
+
+	for by := 0; by < h; by += block {
+		for bx := 0; bx < w; bx += block {
+			endX, endY := bx+block, by+block
+			if endX > w {
+				endX = w
+			}
+			if endY > h {
+				endY = h
+			}
+
+			var sumR, sumG, sumB, sumA, count int
+			for y := by; y < endY; y++ {
+				for x := bx; x < endX; x++ {
+					i := img.PixOffset(x, y)
+					a := int(img.Pix[i+3])
+					sumR += int(img.Pix[i]) * a
+					sumG += int(img.Pix[i+1]) * a
+					sumB += int(img.Pix[i+2]) * a
+					sumA += a
+					count++
+				}
+			}
+
+			var avg [4]uint8
+			if sumA > 0 {
+				avg = [4]uint8{uint8(sumR / sumA), uint8(sumG / sumA), uint8(sumB / sumA), uint8(sumA / count)}
+			}
+			for y := by; y < endY; y++ {
+				for x := bx; x < endX; x++ {
+					copy(out.Pix[out.PixOffset(x, y):], avg[:])
+				}
+			}
+		}
+	}
+	return out
+}
+
+// blurImage - Three box blur passes (close to a gaussian), done on premultiplied
+// colors so transparent edges don't bleed dark halos
+func blurImage(img *image.NRGBA, radius int) *image.NRGBA {
+	premul := image.NewRGBA(img.Rect)
+	draw.Draw(premul, premul.Bounds(), img, image.Point{}, draw.Src)
+
+	for pass := 0; pass < 3; pass++ {
+		premul = boxBlurRGBA(premul, radius)
+	}
+
+	out := image.NewNRGBA(img.Rect)
+	draw.Draw(out, out.Bounds(), premul, image.Point{}, draw.Src)
+	return out
+}
+
+// sharpenImage - Unsharp mask: push every pixel away from a slightly blurred copy
+func sharpenImage(img *image.NRGBA, amount int) *image.NRGBA {
+	blurred := blurImage(img, 1)
+	out := image.NewNRGBA(img.Rect)
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			v := int(img.Pix[i+c]) + amount*(int(img.Pix[i+c])-int(blurred.Pix[i+c]))
+			if v < 0 {
+				v = 0
+			} else if v > 255 {
+				v = 255
+			}
+			out.Pix[i+c] = uint8(v)
+		}
+		out.Pix[i+3] = img.Pix[i+3]
+	}
+	return out
+}
+
+// boxBlurRGBA - One horizontal and one vertical sliding window pass
+func boxBlurRGBA(img *image.RGBA, radius int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	tmp := image.NewRGBA(img.Rect)
+	out := image.NewRGBA(img.Rect)
+
+	for y := 0; y < h; y++ {
+		boxBlurLine(img.Pix, tmp.Pix, y*img.Stride, 4, w, radius)
+	}
+	for x := 0; x < w; x++ {
+		boxBlurLine(tmp.Pix, out.Pix, x*4, tmp.Stride, h, radius)
+	}
+	return out
+}
+
+// boxBlurLine - Average of 2*radius+1 pixels along one row or column, edges clamped
+func boxBlurLine(src, dst []uint8, base, step, length, radius int) {
+	window := 2*radius + 1
+	at := func(i int) int {
+		if i < 0 {
+			i = 0
+		} else if i >= length {
+			i = length - 1
+		}
+		return base + i*step
+	}
+
+	var sum [4]int
+	for i := -radius; i <= radius; i++ {
+		offset := at(i)
+		for c := 0; c < 4; c++ {
+			sum[c] += int(src[offset+c])
+		}
+	}
+
+	for i := 0; i < length; i++ {
+		offset := base + i*step
+		for c := 0; c < 4; c++ {
+			dst[offset+c] = uint8(sum[c] / window)
+		}
+		add, remove := at(i+radius+1), at(i-radius)
+		for c := 0; c < 4; c++ {
+			sum[c] += int(src[add+c]) - int(src[remove+c])
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// imagetools.go - .compress to a target size, .resize to exact dimensions, .asdoc to resend media as a document
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	xdraw "golang.org/x/image/draw"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "compress",
+		CmdDescription: "kecilin ukuran file gambar sampai target KB",
+		CmdUsage:       fmt.Sprintf(".compress [kb] (reply gambar, default %d KB)", compressDefaultKB),
+		CmdExamples:    []string{".compress", ".compress 100", ".compress 500kb"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        compressCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "resize",
+		CmdDescription: "ubah ukuran gambar",
+		CmdUsage:       ".resize <WxH|Wx|xH|persen%> (reply gambar)",
+		CmdExamples:    []string{".resize 800x600", ".resize 1080x", ".resize x720", ".resize 50%"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        resizeCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "asdoc",
+		CmdAliases:     []string{"todoc"},
+		CmdDescription: "kirim ulang media sebagai dokumen (kualitas asli)",
+		CmdUsage:       ".asdoc (reply gambar/video/audio/stiker)",
+		CmdCooldown:    5 * time.Second,
+		Handler:        asDocumentCommand,
+	})
+}
+
+// Image tool limits
+const (
+	compressDefaultKB   = 200
+	compressMinKB       = 10
+	compressMaxKB       = 5000
+	compressMinQuality  = 10
+	compressMaxQuality  = 95
+	compressMinSide     = 64
+	resizeMaxSide       = 4096
+	resizeMaxPercent    = 400
+	outputJPEGQuality   = 90
+	asDocumentMaxBytes  = 100 * 1024 * 1024
+	asDocumentNameStamp = "20060102_150405"
+)
+
+// documentExtensions - File extensions for the mimetypes WhatsApp media usually has
+var documentExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"video/mp4":  ".mp4",
+	"audio/ogg":  ".ogg",
+	"audio/mpeg": ".mp3",
+	"audio/mp4":  ".m4a",
+	"audio/aac":  ".aac",
+}
+
+func compressCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	targetKB := compressDefaultKB
+	if len(ctx.Args) > 0 {
+		kb, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(ctx.Args[0]), "kb"))
+		if err != nil || kb < compressMinKB || kb > compressMaxKB {
+			return fmt.Sprintf("format: %scompress [kb], targetnya %d-%d KB", prefix, compressMinKB, compressMaxKB)
+		}
+		targetKB = kb
+	}
+	if bot.quotedImageMessage(ctx.Message) == nil {
+		return "reply gambar dulu biar bisa dikompres"
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityImage, func(jobCtx context.Context) string {
+		return bot.CompressImageHandler(jobCtx, ctx.Sender, ctx.Message, targetKB*1024)
+	})
+}
+
+func resizeCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	if len(ctx.Args) == 0 {
+		return fmt.Sprintf("format: %sresize <WxH|Wx|xH|persen%%>, contoh %sresize 800x600", prefix, prefix)
+	}
+	spec, err := parseResizeSpec(ctx.Args[0])
+	if err != nil {
+		return fmt.Sprintf("%s. contoh: %sresize 800x600, %sresize 1080x, %sresize 50%%", err.Error(), prefix, prefix, prefix)
+	}
+	if bot.quotedImageMessage(ctx.Message) == nil {
+		return "reply gambar dulu biar bisa diresize"
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityImage, func(jobCtx context.Context) string {
+		return bot.ResizeImageHandler(jobCtx, ctx.Sender, ctx.Message, spec)
+	})
+}
+
+func asDocumentCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+
+	media, mimetype, _, size := documentSource(ctx.Message)
+	if media == nil {
+		return "reply gambar, video, audio, atau stiker dulu biar bisa dikirim sebagai dokumen"
+	}
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+	if size > asDocumentMaxBytes {
+		return fmt.Sprintf("filenya kegedean, maksimal %d MB", asDocumentMaxBytes/1024/1024)
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityImage, func(jobCtx context.Context) string {
+		return bot.AsDocumentHandler(jobCtx, ctx.Sender, ctx.Message, mimetype)
+	})
+}
+
+// CompressImageHandler - Re-encode as JPEG at the best quality that fits targetBytes,
+// shrinking the image when even the lowest quality is too big
+func (bot *WhatsAppBot) CompressImageHandler(ctx context.Context, sender types.JID, msg *events.Message, targetBytes int) string {
+	fmt.Printf("📦 PROCESSING: Compressing image for +%s (target %d KB)\n", sender.User, targetBytes/1024)
+
+	imageData, _, err := bot.downloadMedia(ctx, msg)
+	if err != nil {
+		fmt.Printf("❌ Failed to download image: %v\n", err)
+		return "yah gagal download gambarnya. coba lagi ya"
+	}
+	img, format, err := decodeStillImage(imageData)
+	if err != nil {
+		fmt.Printf("❌ Failed to decode image: %v\n", err)
+		return "format gambarnya ga didukung nih"
+	}
+	if format == "jpeg" && len(imageData) <= targetBytes {
+		return fmt.Sprintf("gambarnya udah cuma %d KB, ga perlu dikompres lagi", len(imageData)/1024)
+	}
+
+	jpegData, bounds, quality, err := compressJPEG(ctx, img, targetBytes)
+	if err != nil {
+		fmt.Printf("❌ Failed to compress image: %v\n", err)
+		return "waduh gagal kompres gambarnya: " + err.Error()
+	}
+
+	caption := fmt.Sprintf("📦 %d KB → %d KB (%dx%d, kualitas %d)",
+		len(imageData)/1024, len(jpegData)/1024, bounds.Dx(), bounds.Dy(), quality)
+	if err := bot.sendImageWithCaption(msg.Info.Chat, jpegData, caption, originalMediaMessageID(msg)); err != nil {
+		fmt.Printf("❌ Failed to send image: %v\n", err)
+		return "yah gagal kirim gambarnya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Compressed image sent to +%s (%d -> %d bytes)\n", sender.User, len(imageData), len(jpegData))
+	return ""
+}
+
+// ResizeImageHandler - Scale to the requested size, keeping JPEG as JPEG and everything else as PNG
+func (bot *WhatsAppBot) ResizeImageHandler(ctx context.Context, sender types.JID, msg *events.Message, spec resizeSpec) string {
+	fmt.Printf("📐 PROCESSING: Resizing image for +%s (%s)\n", sender.User, spec)
+
+	imageData, _, err := bot.downloadMedia(ctx, msg)
+	if err != nil {
+		fmt.Printf("❌ Failed to download image: %v\n", err)
+		return "yah gagal download gambarnya. coba lagi ya"
+	}
+	img, format, err := decodeStillImage(imageData)
+	if err != nil {
+		fmt.Printf("❌ Failed to decode image: %v\n", err)
+		return "format gambarnya ga didukung nih"
+	}
+
+	bounds := img.Bounds()
+	width, height, err := spec.Target(bounds.Dx(), bounds.Dy())
+	if err != nil {
+		return err.Error()
+	}
+
+	resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, xdraw.Src, nil)
+
+	output, err := encodeLikeSource(resized, format)
+	if err != nil {
+		fmt.Printf("❌ Failed to encode image: %v\n", err)
+		return "waduh gagal resize gambarnya: " + err.Error()
+	}
+
+	caption := fmt.Sprintf("📐 %dx%d → %dx%d (%d KB)", bounds.Dx(), bounds.Dy(), width, height, len(output)/1024)
+	if err := bot.sendImageWithCaption(msg.Info.Chat, output, caption, originalMediaMessageID(msg)); err != nil {
+		fmt.Printf("❌ Failed to send image: %v\n", err)
+		return "yah gagal kirim gambarnya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Resized image sent to +%s (%dx%d)\n", sender.User, width, height)
+	return ""
+}
+
+// AsDocumentHandler - Download the media untouched and send it back as a document
+func (bot *WhatsAppBot) AsDocumentHandler(ctx context.Context, sender types.JID, msg *events.Message, mimetype string) string {
+	fmt.Printf("📄 PROCESSING: Resending media as document for +%s (%s)\n", sender.User, mimetype)
+
+	media, _, stem, _ := documentSource(msg)
+	data, err := bot.client.Download(ctx, media)
+	if err != nil {
+		fmt.Printf("❌ Failed to download media: %v\n", err)
+		return "yah gagal download medianya nih. coba lagi ya"
+	}
+	// The announced size is only the sender's claim, check what actually came down
+	if len(data) > asDocumentMaxBytes {
+		fmt.Printf("⚠️ Downloaded media is %d bytes, over the document limit\n", len(data))
+		return fmt.Sprintf("filenya kegedean, maksimal %d MB", asDocumentMaxBytes/1024/1024)
+	}
+
+	filename := stem + "_" + time.Now().Format(asDocumentNameStamp) + documentExtension(mimetype)
+	if err := bot.sendDocument(msg.Info.Chat, data, mimetype, filename, originalMediaMessageID(msg)); err != nil {
+		fmt.Printf("❌ Failed to send document: %v\n", err)
+		return "yah gagal kirim dokumennya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Document sent to +%s (%d bytes)\n", sender.User, len(data))
+	return ""
+}
+
+// documentSource - Media sent directly or quoted: the downloadable message, its mimetype,
+// a file name stem and the announced size
+func documentSource(msg *events.Message) (whatsmeow.DownloadableMessage, string, string, uint64) {
+	message := msg.Message
+	if message.GetImageMessage() == nil && message.GetVideoMessage() == nil &&
+		message.GetAudioMessage() == nil && message.GetStickerMessage() == nil {
+		message = message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage()
+	}
+
+	switch {
+	case message.GetImageMessage() != nil:
+		media := message.GetImageMessage()
+		return media, media.GetMimetype(), "image", media.GetFileLength()
+	case message.GetVideoMessage() != nil:
+		media := message.GetVideoMessage()
+		return media, media.GetMimetype(), "video", media.GetFileLength()
+	case message.GetAudioMessage() != nil:
+		media := message.GetAudioMessage()
+		return media, media.GetMimetype(), "audio", media.GetFileLength()
+	case message.GetStickerMessage() != nil:
+		media := message.GetStickerMessage()
+		return media, media.GetMimetype(), "sticker", media.GetFileLength()
+	}
+	return nil, "", "", 0
+}
+
+// documentExtension - File extension for a mimetype ("audio/ogg; codecs=opus" -> ".ogg")
+func documentExtension(mimetype string) string {
+	base := strings.TrimSpace(strings.SplitN(mimetype, ";", 2)[0])
+	if ext, ok := documentExtensions[base]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(base); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// resizeSpec - Target from .resize: exact width/height (0 = follow aspect ratio) or a percentage
+type resizeSpec struct {
+	Width   int
+	Height  int
+	Percent int
+}
+
+// String - Short summary for logs
+func (s resizeSpec) String() string {
+	if s.Percent > 0 {
+		return fmt.Sprintf("%d%%", s.Percent)
+	}
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// parseResizeSpec - "800x600", "800x", "x600" or "50%"
+func parseResizeSpec(arg string) (resizeSpec, error) {
+	arg = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(arg)), "×", "x")
+
+	if strings.HasSuffix(arg, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+		if err != nil || percent < 1 || percent > resizeMaxPercent {
+			return resizeSpec{}, fmt.Errorf("persennya harus 1-%d", resizeMaxPercent)
+		}
+		return resizeSpec{Percent: percent}, nil
+	}
+
+	widthText, heightText, ok := strings.Cut(arg, "x")
+	if !ok || (widthText == "" && heightText == "") {
+		return resizeSpec{}, fmt.Errorf("ukurannya ga valid")
+	}
+
+	var spec resizeSpec
+	var err error
+	if widthText != "" {
+		if spec.Width, err = strconv.Atoi(widthText); err != nil || spec.Width < 1 || spec.Width > resizeMaxSide {
+			return resizeSpec{}, fmt.Errorf("lebarnya harus 1-%d", resizeMaxSide)
+		}
+	}
+	if heightText != "" {
+		if spec.Height, err = strconv.Atoi(heightText); err != nil || spec.Height < 1 || spec.Height > resizeMaxSide {
+			return resizeSpec{}, fmt.Errorf("tingginya harus 1-%d", resizeMaxSide)
+		}
+	}
+	return spec, nil
+}
+
+// Target - Output size for a source image, missing sides following the aspect ratio
+func (s resizeSpec) Target(srcWidth, srcHeight int) (int, int, error) {
+	width, height := s.Width, s.Height
+	switch {
+	case s.Percent > 0:
+		width, height = srcWidth*s.Percent/100, srcHeight*s.Percent/100
+	case width == 0:
+		width = srcWidth * height / srcHeight
+	case height == 0:
+		height = srcHeight * width / srcWidth
+	}
+
+	width, height = max(1, width), max(1, height)
+	if width > resizeMaxSide || height > resizeMaxSide {
+		return 0, 0, fmt.Errorf("hasilnya jadi %dx%d, kegedean (maksimal %d px per sisi)", width, height, resizeMaxSide)
+	}
+	return width, height, nil
+}
+
+// compressJPEG - Highest JPEG quality under targetBytes; scales down by a quarter each round
+// when the lowest quality still doesn't fit
+func compressJPEG(ctx context.Context, img image.Image, targetBytes int) ([]byte, image.Rectangle, int, error) {
+	current := flattenImage(limitImageSide(img, resizeMaxSide))
+
+	for {
+		if ctx.Err() != nil {
+			return nil, image.Rectangle{}, 0, ctx.Err()
+		}
+
+		data, quality, err := fitJPEGQuality(current, targetBytes)
+		if err != nil {
+			return nil, image.Rectangle{}, 0, err
+		}
+		if data != nil {
+			return data, current.Bounds(), quality, nil
+		}
+
+		bounds := current.Bounds()
+		width, height := bounds.Dx()*3/4, bounds.Dy()*3/4
+		if width < compressMinSide || height < compressMinSide {
+			return nil, image.Rectangle{}, 0, fmt.Errorf("ga bisa sekecil %d KB", targetBytes/1024)
+		}
+		fmt.Printf("⚠️ JPEG still too large at quality %d, scaling to %dx%d\n", compressMinQuality, width, height)
+
+		smaller := image.NewRGBA(image.Rect(0, 0, width, height))
+		xdraw.CatmullRom.Scale(smaller, smaller.Bounds(), current, bounds, xdraw.Src, nil)
+		current = smaller
+	}
+}
+
+// fitJPEGQuality - Binary search for the best quality that fits; nil data when none does
+func fitJPEGQuality(img image.Image, targetBytes int) ([]byte, int, error) {
+	var best []byte
+	bestQuality := 0
+	low, high := compressMinQuality, compressMaxQuality
+
+	for low <= high {
+		quality := (low + high) / 2
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, 0, fmt.Errorf("gagal encode JPEG: %v", err)
+		}
+
+		if buf.Len() <= targetBytes {
+			best, bestQuality = buf.Bytes(), quality
+			low = quality + 1
+		} else {
+			high = quality - 1
+		}
+	}
+	return best, bestQuality, nil
+}
+
+// decodeStillImage - Decode JPEG/PNG/GIF/WebP, returning the format name like image.Decode
+func decodeStillImage(data []byte) (image.Image, string, error) {
+	if isWebP(data) {
+		img, err := decodeWebPNative(data)
+		return img, "webp", err
+	}
+	return image.Decode(bytes.NewReader(data))
+}
+
+// encodeLikeSource - JPEG stays JPEG (smaller, no alpha to keep), everything else becomes PNG
+func encodeLikeSource(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: outputJPEGQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal encode hasil: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,197 @@
+// limiter.go - Fair message scheduling, per-chat concurrency caps and command cooldowns
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// FairScheduler - Replaces the single global rate limiter channel. Slots are handed out
+// round-robin across senders, with caps per sender and per chat, so one busy user or
+// chat can't hold every slot while others wait.
+type FairScheduler struct {
+	mutex        sync.Mutex
+	maxActive    int
+	perSender    int
+	perChat      int
+	active       int
+	senderActive map[string]int
+	chatActive   map[string]int
+	queues       map[string][]*scheduleTicket // waiting tickets per sender (FIFO)
+	order        []string                     // senders with waiting tickets, round-robin order
+	waiting      int
+}
+
+type scheduleTicket struct {
+	chat  string
+	ready chan struct{}
+}
+
+// NewFairScheduler - Create a scheduler with global, per-sender and per-chat limits
+func NewFairScheduler(maxActive, perSender, perChat int) *FairScheduler {
+	return &FairScheduler{
+		maxActive:    maxActive,
+		perSender:    perSender,
+		perChat:      perChat,
+		senderActive: make(map[string]int),
+		chatActive:   make(map[string]int),
+		queues:       make(map[string][]*scheduleTicket),
+	}
+}
+
+// Acquire - Block until the sender may run in the chat; call the returned func when done
+func (s *FairScheduler) Acquire(sender, chat string) func() {
+	s.mutex.Lock()
+	if len(s.queues[sender]) == 0 && s.canRun(sender, chat) {
+		s.grant(sender, chat)
+		s.mutex.Unlock()
+		return s.releaseFunc(sender, chat)
+	}
+
+	ticket := &scheduleTicket{chat: chat, ready: make(chan struct{})}
+	if len(s.queues[sender]) == 0 {
+		s.order = append(s.order, sender)
+	}
+	s.queues[sender] = append(s.queues[sender], ticket)
+	s.waiting++
+	s.mutex.Unlock()
+
+	<-ticket.ready
+	return s.releaseFunc(sender, chat)
+}
+
+// Waiting - Number of messages waiting for a slot
+func (s *FairScheduler) Waiting() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.waiting
+}
+
+func (s *FairScheduler) canRun(sender, chat string) bool {
+	return s.active < s.maxActive &&
+		s.senderActive[sender] < s.perSender &&
+		s.chatActive[chat] < s.perChat
+}
+
+func (s *FairScheduler) grant(sender, chat string) {
+	s.active++
+	s.senderActive[sender]++
+	s.chatActive[chat]++
+}
+
+func (s *FairScheduler) releaseFunc(sender, chat string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			s.active--
+			if s.senderActive[sender]--; s.senderActive[sender] <= 0 {
+				delete(s.senderActive, sender)
+			}
+			if s.chatActive[chat]--; s.chatActive[chat] <= 0 {
+				delete(s.chatActive, chat)
+			}
+			s.dispatch()
+		})
+	}
+}
+
+// dispatch - Grant free slots to waiting senders in round-robin order (mutex held)
+func (s *FairScheduler) dispatch() {
+	skipped := 0
+	for len(s.order) > 0 && skipped < len(s.order) && s.active < s.maxActive {
+		sender := s.order[0]
+		s.order = s.order[1:]
+
+		queue := s.queues[sender]
+		ticket := queue[0]
+		if !s.canRun(sender, ticket.chat) {
+			s.order = append(s.order, sender)
+			skipped++
+			continue
+		}
+
+		s.grant(sender, ticket.chat)
+		s.waiting--
+		close(ticket.ready)
+		skipped = 0
+
+		if len(queue) == 1 {
+			delete(s.queues, sender)
+		} else {
+			s.queues[sender] = queue[1:]
+			s.order = append(s.order, sender) // back of the line
+		}
+	}
+}
+
+// CooldownTracker - Remembers when senders last used commands
+type CooldownTracker struct {
+	mutex    sync.Mutex
+	lastUsed map[string]time.Time
+	notified map[string]bool // "wait N seconds" already sent for the current window
+}
+
+// NewCooldownTracker - Create an empty cooldown tracker
+func NewCooldownTracker() *CooldownTracker {
+	return &CooldownTracker{
+		lastUsed: make(map[string]time.Time),
+		notified: make(map[string]bool),
+	}
+}
+
+// Check - Remaining wait for sender/command across the sender-wide and per-command windows.
+// When nothing is pending the use is recorded. notify is false if the sender was already told.
+func (c *CooldownTracker) Check(sender, command string, senderWindow, commandWindow time.Duration) (wait time.Duration, notify bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	senderKey := sender
+	commandKey := sender + "|" + command
+
+	if last, ok := c.lastUsed[senderKey]; ok && now.Sub(last) < senderWindow {
+		wait = senderWindow - now.Sub(last)
+	}
+	if last, ok := c.lastUsed[commandKey]; ok && now.Sub(last) < commandWindow {
+		if remaining := commandWindow - now.Sub(last); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait > 0 {
+		notify = !c.notified[commandKey]
+		c.notified[commandKey] = true
+		return wait, notify
+	}
+
+	c.lastUsed[senderKey] = now
+	c.lastUsed[commandKey] = now
+	delete(c.notified, commandKey)
+	c.cleanup(now)
+	return 0, false
+}
+
+// cleanup - Drop entries older than a few minutes so the maps don't grow forever (mutex held)
+func (c *CooldownTracker) cleanup(now time.Time) {
+	if len(c.lastUsed) < 1000 {
+		return
+	}
+	for key, last := range c.lastUsed {
+		if now.Sub(last) > 10*time.Minute {
+			delete(c.lastUsed, key)
+			delete(c.notified, key)
+		}
+	}
+}
+
+// checkCooldown - Cooldown check for a command call; owners and bot admins are exempt
+func (bot *WhatsAppBot) checkCooldown(ctx *CommandContext, cmd Command) (time.Duration, bool) {
+	if ctx.baseRole() >= RoleBotAdmin {
+		return 0, false
+	}
+	limits := &bot.config.Limits
+	return bot.cooldowns.Check(ctx.Sender.User, cmd.Name(), limits.SenderCooldown(), limits.CommandCooldown(cmd))
+}
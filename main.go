@@ -6,9 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"strings"
 	"sync"
@@ -28,12 +28,23 @@ import (
 
 type WhatsAppBot struct {
 	client            *whatsmeow.Client
-	rateLimiter       chan struct{}
+	scheduler         *FairScheduler
+	cooldowns         *CooldownTracker
+	mediaQueue        *MediaQueue
+	sandbox           *ToolSandbox
+	mediaCache        *MediaCache
+	recentMedia       *RecentMediaBuffer
+	rootCtx           context.Context // cancelled when shutdown grace period runs out
+	cancelRoot        context.CancelFunc
 	wg                sync.WaitGroup
 	mutex             sync.RWMutex
 	processedMessages int64
 	startTime         time.Time
 	httpClient        *http.Client
+	commands          *CommandRegistry
+	config            *BotConfig
+	store             *BotStore
+	ffmpegEncoders    map[string]bool // probed once by checkWebPToolsAvailability
 }
 
 func NewWhatsAppBot() *WhatsAppBot {
@@ -48,39 +59,49 @@ func NewWhatsAppBot() *WhatsAppBot {
 		log.Fatal("Failed to get device:", err)
 	}
 
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	store, err := OpenBotStore(botDBPath)
+	if err != nil {
+		log.Fatal("Failed to open bot database:", err)
+	}
+
+	sandbox, err := NewToolSandbox(config.Limits)
+	if err != nil {
+		log.Fatal("Failed to prepare tool sandbox:", err)
+	}
+
+	mediaCache, err := NewMediaCache(config.Media.CacheDir, config.Media.CacheMaxBytes(), store)
+	if err != nil {
+		log.Fatal("Failed to prepare media cache:", err)
+	}
+
 	clientLog := waLog.Stdout("Client", "ERROR", false)
 	client := whatsmeow.NewClient(deviceStore, clientLog)
 
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+
 	return &WhatsAppBot{
 		client:      client,
-		rateLimiter: make(chan struct{}, 50), // Increased rate limit
+		rootCtx:     rootCtx,
+		cancelRoot:  cancelRoot,
+		scheduler:   NewFairScheduler(config.Limits.MaxConcurrent, config.Limits.PerSender, config.Limits.PerChat),
+		cooldowns:   NewCooldownTracker(),
+		mediaQueue:  NewMediaQueue(config.Limits.MediaWorkers),
+		sandbox:     sandbox,
+		mediaCache:  mediaCache,
+		recentMedia: NewRecentMediaBuffer(),
 		startTime:   time.Now(),
 		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		commands:    defaultRegistry,
+		config:      config,
+		store:       store,
 	}
 }
 
-// isOksobatSIJAGroup - Check if this is the OksobatSIJA group with flexible matching
-func (bot *WhatsAppBot) isOksobatSIJAGroup(chatJID types.JID) bool {
-	if !strings.Contains(chatJID.String(), "@g.us") {
-		return false // Not a group
-	}
-
-	// Try to get group info first
-	groupInfo, err := bot.client.GetGroupInfo(chatJID)
-	if err == nil {
-		groupName := strings.ToLower(groupInfo.Name)
-		// Match for OksobatSIJA Exclusive Edition💎 specifically
-		if strings.Contains(groupName, "oksobatsija exclusive edition") ||
-			strings.Contains(groupName, "oksobatsija exclusive") ||
-			strings.Contains(groupName, "exclusive edition") && strings.Contains(groupName, "oksobat") {
-			fmt.Printf("✅ Detected OksobatSIJA Exclusive Edition group via name: %s\n", groupInfo.Name)
-			return true
-		}
-	}
-
-	return false
-}
-
 func (bot *WhatsAppBot) Start() {
 	fmt.Println("🤖 WhatsApp Bot - Animated Sticker Edition")
 	fmt.Println("🎞️ GIF → Animated WebP Sticker Support")
@@ -89,6 +110,9 @@ func (bot *WhatsAppBot) Start() {
 	// Check WebP tools availability
 	bot.checkWebPToolsAvailability()
 
+	// Media conversions run on their own worker pool
+	bot.mediaQueue.Start()
+
 	bot.client.AddEventHandler(func(evt interface{}) {
 		switch v := evt.(type) {
 		case *events.Message:
@@ -154,8 +178,25 @@ func (bot *WhatsAppBot) Start() {
 	<-c
 
 	fmt.Println("Shutting down...")
-	bot.wg.Wait()
+
+	// Give in-flight jobs a grace period, then cancel them (kills running tools)
+	done := make(chan struct{})
+	go func() {
+		bot.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownGracePeriod):
+		fmt.Println("⏱️ Grace period over - cancelling running media jobs")
+		bot.cancelRoot()
+		<-done
+	}
+	bot.cancelRoot()
+	bot.mediaQueue.Stop()
+	bot.sandbox.Close()
 	bot.client.Disconnect()
+	bot.store.Close()
 	fmt.Println("Bye")
 }
 
@@ -167,17 +208,23 @@ func (bot *WhatsAppBot) handleMessage(msg *events.Message) {
 		return
 	}
 
-	bot.rateLimiter <- struct{}{}
-	defer func() { <-bot.rateLimiter }()
+	// Remember media for .s all and album captions, before waiting for a slot
+	msg = unwrapAlbumChild(msg)
+	bot.recentMedia.Add(msg)
 
-	// Extract message text from different message types
-	messageText := bot.extractMessageText(msg)
 	sender := msg.Info.Sender
 	chatJID := msg.Info.Chat
+
+	// Fair slot: round-robin across senders with per-sender and per-chat caps
+	release := bot.scheduler.Acquire(sender.User, chatJID.String())
+	defer release()
+
+	// Extract message text from different message types
+	messageText := bot.extractMessageText(msg)
 	isGroup := strings.Contains(chatJID.String(), "@g.us")
 
-	// Check if this is OksobatSIJA group
-	isOksobatGroup := bot.isOksobatSIJAGroup(chatJID)
+	// Resolve the policy configured for this chat
+	policy := bot.policyFor(chatJID)
 
 	// Get sender display name
 	senderName := sender.User
@@ -190,15 +237,14 @@ func (bot *WhatsAppBot) handleMessage(msg *events.Message) {
 	chatInfo := ""
 	if isGroup {
 		chatType = "👥 GROUP"
-		if isOksobatGroup {
-			chatType = "💎 OKSOBAT-EXCLUSIVE"
+		if policy.Exclusive {
+			chatType = "💎 EXCLUSIVE"
 		}
-		// Get group name if possible
-		groupName := chatJID.User
-		if len(groupName) > 15 {
-			groupName = groupName[:15] + "..."
+		// Show the full group JID so it can be copied into config.json
+		chatInfo = fmt.Sprintf(" (%s)", chatJID.String())
+		if policy.Name != "" {
+			chatInfo = fmt.Sprintf(" (%s - %s)", policy.Name, chatJID.String())
 		}
-		chatInfo = fmt.Sprintf(" (%s)", groupName)
 	}
 
 	// Enhanced message logging with proper timestamp
@@ -214,43 +260,42 @@ func (bot *WhatsAppBot) handleMessage(msg *events.Message) {
 
 	fmt.Printf("📊 Total processed: %d\n", currentCount)
 
-	// NEW COMMAND FILTERING RULES:
-	if messageText != "" && strings.HasPrefix(messageText, "/") {
-		if isOksobatGroup {
-			// OksobatSIJA Exclusive: BLOCK ALL "/" commands - NO RESPONSE
-			fmt.Printf("🚫 BLOCKED: OksobatSIJA Exclusive - all '/' commands not allowed (silent)\n")
-			fmt.Println("----------------------------------------")
-			return
+	prefix := policy.MatchPrefix(messageText)
+
+	// COMMAND FILTERING RULES (from the chat policy):
+	if prefix != "" && !policy.AcceptsPrefix(prefix) {
+		if policy.SilentBlock {
+			fmt.Printf("🚫 BLOCKED: prefix '%s' not accepted in this chat (silent)\n", prefix)
 		} else {
-			// Other groups/DM: Only allow specific "/" commands
-			cmd := strings.ToLower(strings.Split(messageText, " ")[0])
-			allowedLegacyCommands := []string{"/help", "/sticker", "/s", "/tagall"}
-
-			isAllowed := false
-			for _, allowed := range allowedLegacyCommands {
-				if cmd == allowed {
-					isAllowed = true
-					break
-				}
-			}
+			fmt.Printf("🚫 BLOCKED: prefix '%s' not accepted in this chat\n", prefix)
+			bot.sendReply(chatJID, fmt.Sprintf("perintah '%s' sudah diganti dengan '%s', coba %shelp untuk bantuan",
+				prefix, policy.PrimaryPrefix(), policy.PrimaryPrefix()), msg.Info.ID, sender)
+		}
+		fmt.Println("----------------------------------------")
+		return
+	}
 
-			if !isAllowed {
-				fmt.Printf("🚫 BLOCKED: '/' command not in allowed list - use '.' commands instead\n")
-				if isGroup {
-					bot.sendReply(chatJID, "perintah '/' sudah diganti dengan '.', coba .help untuk bantuan", msg.Info.ID, sender)
-				} else {
-					bot.sendReply(chatJID, "perintah '/' sudah diganti dengan '.', coba .help untuk bantuan", msg.Info.ID, sender)
-				}
-				fmt.Println("----------------------------------------")
-				return
+	if prefix == "/" && prefix != policy.PrimaryPrefix() {
+		// Legacy "/" only works for commands registered as legacy
+		name, _ := splitCommand(messageText, prefix)
+		registered, ok := bot.commands.Lookup(name)
+		isAllowed := ok && registered.Legacy()
+
+		if !isAllowed {
+			fmt.Printf("🚫 BLOCKED: '/' command not in allowed list - use '%s' commands instead\n", policy.PrimaryPrefix())
+			if !policy.SilentBlock {
+				bot.sendReply(chatJID, fmt.Sprintf("perintah '/' sudah diganti dengan '%s', coba %shelp untuk bantuan",
+					policy.PrimaryPrefix(), policy.PrimaryPrefix()), msg.Info.ID, sender)
 			}
+			fmt.Println("----------------------------------------")
+			return
 		}
 	}
 
 	// Check if it's a command
-	if messageText != "" && (strings.HasPrefix(messageText, "/") || strings.HasPrefix(messageText, ".")) {
+	if prefix != "" {
 		fmt.Printf("⚡ Processing command: %s\n", strings.Split(messageText, " ")[0])
-		bot.processCommand(chatJID, sender, messageText, isGroup, msg)
+		bot.processCommand(chatJID, sender, messageText, prefix, isGroup, policy, msg)
 	} else if messageText == "" {
 		fmt.Printf("⚠️ Empty message received - might be media/unsupported type\n")
 	} else {
@@ -322,236 +367,61 @@ func (bot *WhatsAppBot) extractQuotedMessageText(msg *events.Message) string {
 	return ""
 }
 
-func (bot *WhatsAppBot) processCommand(chatJID, sender types.JID, command string, isGroup bool, originalMsg *events.Message) {
-	parts := strings.Split(strings.TrimSpace(command), " ")
-	if len(parts) == 0 {
+func (bot *WhatsAppBot) processCommand(chatJID, sender types.JID, command, prefix string, isGroup bool, policy *GroupPolicy, originalMsg *events.Message) {
+	name, args := splitCommand(command, prefix)
+	if name == "" {
 		return
 	}
 
-	cmd := strings.ToLower(parts[0])
+	cmd := prefix + name
 	var response string
 
-	// Check if this is OksobatSIJA group
-	isOksobatGroup := bot.isOksobatSIJAGroup(chatJID)
-
-	fmt.Printf("🔍 Command detected: '%s' (OksobatGroup: %v)\n", cmd, isOksobatGroup)
+	fmt.Printf("🔍 Command detected: '%s' (policy: %s)\n", cmd, policy.Name)
 	startTime := time.Now()
 
-	switch cmd {
-	// DOT COMMANDS - Primary commands for all chats
-	case ".hi":
-		if isOksobatGroup {
-			response = `halo OksobatSIJA Exclusive Edition! 💎
-
-Commands untuk grup eksklusif ini:
-.hi - menu utama
-.sticker atau .s - konversi gambar/gif ke stiker WebP (ANIMATED!)
-.toimg - konversi stiker ke gambar PNG
-.tagall - mention semua member
-.calendar - info tanggal hari ini WIB
-.stats - statistik bot
-.help - bantuan lengkap
-.tools - cek status WebP tools
-
-special bot untuk OksobatSIJA Exclusive only! 🤖✨`
-		} else if isGroup {
-			response = `halo grup! 👋
-
-Commands utama (dot commands):
-.hi - menu utama
-.sticker atau .s - gambar/gif ke stiker (ANIMATED WebP)
-.toimg - stiker ke gambar
-.tagall - mention semua (grup only)
-.calendar - tanggal hari ini WIB
-.stats - statistik bot
-.help - bantuan lengkap
-.tools - cek WebP tools
-
-bot siap melayani grup ini! 🤖`
-		} else {
-			response = `halo! 👋
-
-Commands utama (dot commands):
-.hi - menu utama
-.sticker atau .s - gambar/gif ke stiker (ANIMATED WebP)
-.toimg - stiker ke gambar
-.calendar - tanggal hari ini WIB
-.stats - statistik bot
-.help - bantuan lengkap
-.tools - cek WebP tools
-
-bot siap melayani! 🤖`
-		}
-
-	case ".help":
-		if isOksobatGroup {
-			response = `🤖 WhatsApp Bot Helper - OksobatSIJA Exclusive Edition 💎
-
-aku bot eksklusif untuk grup OksobatSIJA!
-
-📋 Commands (dot commands):
-• .hi - menu utama
-• .sticker atau .s - konversi gambar/gif ke stiker WebP (ANIMATED!)
-• .toimg - konversi stiker ke gambar PNG
-• .tagall - mention semua member
-• .calendar - info tanggal hari ini WIB
-• .stats - statistik bot
-• .tools - cek status WebP tools
-
-🎞️ Fitur Animated Sticker:
-• GIF → Animated WebP sticker (bergerak!)
-• Video → Animated WebP sticker
-• Image → Static WebP sticker
-• Auto-resize ke 512x512
-• Fallback ke PNG jika WebP gagal
-• Support gif2webp & FFmpeg
-
-special untuk OksobatSIJA Exclusive only! 💎✨`
-		} else {
-			response = `🤖 WhatsApp Bot Helper - Animated Sticker Edition
-
-aku bot yang bisa convert sticker dengan WebP + animasi!
-
-📋 Commands (dot commands):
-• .hi - menu utama
-• .sticker atau .s - konversi gambar/gif ke stiker WebP (ANIMATED!)
-• .toimg - konversi stiker ke gambar PNG
-• .tagall - mention semua member (grup only)
-• .calendar - info tanggal hari ini WIB
-• .stats - statistik bot
-• .tools - cek status WebP tools
-
-🎞️ Fitur Animated Sticker:
-• GIF → Animated WebP sticker (bergerak!)
-• Video → Animated WebP sticker
-• Image → Static WebP sticker
-• Auto-resize ke 512x512
-• Support gif2webp & FFmpeg
-• Fallback ke PNG jika tools tidak ada
-
-💡 Note: Beberapa perintah lama masih tersedia:
-/help, /sticker, /s, /tagall
-
-animated stickers ftw! 🎞️✨`
-		}
-
-	case ".sticker", ".s":
-		if bot.hasQuotedImage(originalMsg) {
-			response = bot.StickerHandler(sender, originalMsg)
-		} else {
-			response = "reply gambar, gif, atau video dulu dong biar bisa dijadiin stiker WebP (animated!)"
-		}
-
-	case ".toimg":
-		if bot.hasQuotedSticker(originalMsg) {
-			response = bot.ToImageHandler(sender, originalMsg)
-		} else {
-			response = "reply stiker dulu biar bisa dikonversi ke gambar"
-		}
-
-	case ".tagall":
-		if isGroup {
-			quotedText := bot.extractQuotedMessageText(originalMsg)
-			response = bot.TagAllHandler(chatJID, originalMsg.Info.ID, quotedText)
-		} else {
-			response = "command .tagall cuma bisa dipake di grup ya"
-		}
-
-	case ".calendar":
-		response = bot.getCalendarInfo()
-
-	case ".stats":
-		bot.mutex.RLock()
-		count := bot.processedMessages
-		bot.mutex.RUnlock()
-		uptime := time.Since(bot.startTime)
+	registered, ok := bot.commands.Lookup(name)
+	if !ok || (prefix == "/" && prefix != policy.PrimaryPrefix() && !registered.Legacy()) {
+		fmt.Printf("❓ Unknown command: %s\n", cmd)
+		return // No response for unknown commands
+	}
 
-		// Calculate messages per minute
-		minutes := uptime.Minutes()
-		msgPerMin := float64(0)
-		if minutes > 0 {
-			msgPerMin = float64(count) / minutes
-		}
+	ctx := &CommandContext{
+		Bot:     bot,
+		ChatJID: chatJID,
+		Sender:  sender,
+		Message: originalMsg,
+		Prefix:  prefix,
+		Invoked: name,
+		Args:    args,
+		IsGroup: isGroup,
+		Policy:  policy,
+	}
 
-		// Check animation support
-		animationSupport := "❌ disabled"
-		if bot.isToolAvailable("gif2webp") {
-			animationSupport = "✅ gif2webp"
-		} else if bot.isToolAvailable("ffmpeg") {
-			animationSupport = "⚠️ ffmpeg only"
+	if !ctx.HasRole(RoleMember) {
+		fmt.Printf("🚫 Banned user +%s tried %s\n", sender.User, cmd)
+		if policy.SilentBlock {
+			return
 		}
-
-		extraText := ""
-		if isOksobatGroup {
-			extraText = "\nbot eksklusif untuk OksobatSIJA! 💎"
+		response = "kamu lagi dibanned dari bot ini"
+	} else if !policy.IsCommandEnabled(registered.Name()) {
+		fmt.Printf("🚫 Command %s disabled by chat policy\n", registered.Name())
+		if policy.SilentBlock {
+			return
 		}
-
-		response = fmt.Sprintf(`📊 *Bot Statistics*
-
-💬 pesan diproses: *%d*
-⏱️ uptime: *%v*
-📈 rata-rata: *%.1f* msg/menit
-🎞️ animated stickers: %s
-⚡ mode: WebP + concurrent processing
-🚀 response time: < 500ms
-📱 status: online & ready%s
-
-keep sending GIFs! 🎞️🤖`,
-			count,
-			uptime.Truncate(time.Second),
-			msgPerMin,
-			animationSupport,
-			extraText)
-
-	case ".tools":
-		response = bot.getToolsStatus()
-
-	// LEGACY SLASH COMMANDS - Only for non-OksobatSIJA chats and specific commands only
-	case "/help":
-		// Only allowed in non-OksobatSIJA chats
-		response = `🤖 WhatsApp Bot Helper - Legacy Help
-
-perintah utama sudah pindah ke dot commands!
-
-📋 Commands baru (dot commands):
-• .hi - menu utama
-• .sticker atau .s - konversi gambar/gif ke stiker WebP (ANIMATED!)
-• .toimg - konversi stiker ke gambar PNG
-• .tagall - mention semua member (grup only)
-• .calendar - info tanggal hari ini WIB
-• .stats - statistik bot
-• .tools - cek status WebP tools
-
-🎞️ NEW: Animated sticker support!
-• GIF → Bergerak di WhatsApp
-• Video → Animated sticker
-• Image → Static sticker
-
-💡 Perintah legacy yang masih tersedia:
-/help, /sticker, /s, /tagall
-
-gunakan .help untuk bantuan lengkap! 🎞️✨`
-
-	case "/sticker", "/s":
-		// Only allowed in non-OksobatSIJA chats
-		if bot.hasQuotedImage(originalMsg) {
-			response = bot.StickerHandler(sender, originalMsg)
-		} else {
-			response = "reply gambar, gif, atau video dulu dong biar bisa dijadiin stiker WebP (animated!)"
+		response = fmt.Sprintf("command %s lagi dimatiin di sini", cmd)
+	} else if registered.GroupOnly() && !isGroup {
+		response = fmt.Sprintf("command %s cuma bisa dipake di grup ya", cmd)
+	} else if !ctx.HasRole(registered.MinRole()) {
+		fmt.Printf("🔐 +%s is %s, %s needs %s\n", sender.User, ctx.CallerRole().Label(), cmd, registered.MinRole().Label())
+		response = permissionDenied(cmd, registered.MinRole())
+	} else if wait, notify := bot.checkCooldown(ctx, registered); wait > 0 {
+		fmt.Printf("⏳ Cooldown: +%s must wait %v for %s\n", sender.User, wait.Truncate(time.Millisecond), cmd)
+		if !notify {
+			return // already told them during this window
 		}
-
-	case "/tagall":
-		// Only allowed in non-OksobatSIJA chats
-		if isGroup {
-			quotedText := bot.extractQuotedMessageText(originalMsg)
-			response = bot.TagAllHandler(chatJID, originalMsg.Info.ID, quotedText)
-		} else {
-			response = "command /tagall cuma bisa dipake di grup ya"
-		}
-
-	default:
-		fmt.Printf("❓ Unknown command: %s\n", cmd)
-		return // No response for unknown commands
+		response = fmt.Sprintf("sabar ya, tunggu %d detik lagi buat pake %s", int(math.Ceil(wait.Seconds())), cmd)
+	} else {
+		response = registered.Handle(ctx)
 	}
 
 	// Send reply ONLY if there's a response and it's NOT empty
@@ -752,6 +622,7 @@ func (bot *WhatsAppBot) getHijriMonthName(month int) string {
 // getToolsStatus - Get WebP tools installation status with animation focus
 func (bot *WhatsAppBot) getToolsStatus() string {
 	status := "🔧 *WebP Tools Status*\n\n"
+	status += "🧩 static WebP backend: " + bot.webpBackendLabel() + "\n\n"
 
 	// Check gif2webp (MOST IMPORTANT for animated stickers)
 	if bot.isToolAvailable("gif2webp") {
@@ -778,9 +649,7 @@ func (bot *WhatsAppBot) getToolsStatus() string {
 	if bot.isToolAvailable("ffmpeg") {
 		status += "✅ ffmpeg: installed"
 		// Test libwebp support
-		cmd := exec.Command("ffmpeg", "-codecs")
-		output, err := cmd.CombinedOutput()
-		if err == nil && strings.Contains(string(output), "libwebp") {
+		if bot.ffmpegHasLibWebP() {
 			status += " (with libwebp - animation fallback)\n"
 		} else {
 			status += " (libwebp support unknown)\n"
@@ -805,9 +674,34 @@ func (bot *WhatsAppBot) getToolsStatus() string {
 		status += "❌ *NO ANIMATED SUPPORT* - static only\n"
 	}
 
+	status += "\n🎵 *Audio (.tomp3/.tovn):*\n"
+	if bot.isToolAvailable("ffmpeg") {
+		if bot.ffmpegHasEncoder("libmp3lame") {
+			status += "✅ MP3: libmp3lame\n"
+		} else {
+			status += "❌ MP3: libmp3lame not found\n"
+		}
+		if bot.ffmpegHasEncoder("libopus") {
+			status += "✅ voice note: libopus\n"
+		} else {
+			status += "⚠️ voice note: native Opus encoder (libopus not found)\n"
+		}
+	} else {
+		status += "❌ butuh ffmpeg\n"
+	}
+
+	status += "\n🔤 *OCR (.ocr):*\n"
+	if ok, detail := bot.ocrStatus(); ok {
+		status += "✅ " + detail + "\n"
+	} else {
+		status += "❌ " + detail + "\n"
+	}
+
+	status += "\n🛡️ *Sandbox:* " + bot.sandbox.Describe() + "\n"
+
 	status += "\n💡 *Install commands:*\n"
-	status += "ubuntu: `sudo apt install webp imagemagick ffmpeg`\n"
-	status += "macOS: `brew install webp imagemagick ffmpeg`\n"
+	status += "ubuntu: `sudo apt install webp imagemagick ffmpeg tesseract-ocr tesseract-ocr-ind`\n"
+	status += "macOS: `brew install webp imagemagick ffmpeg tesseract tesseract-lang`\n"
 	status += "windows: download WebP tools + FFmpeg\n\n"
 
 	status += "🏆 *Untuk animated sticker terbaik:*\n"
@@ -869,6 +763,39 @@ func (bot *WhatsAppBot) hasQuotedSticker(msg *events.Message) bool {
 	return false
 }
 
+// quotedVideoMessage - Video/GIF message sent directly or quoted in a reply
+func (bot *WhatsAppBot) quotedVideoMessage(msg *events.Message) *waProto.VideoMessage {
+	if videoMsg := msg.Message.GetVideoMessage(); videoMsg != nil {
+		return videoMsg
+	}
+
+	extendedMsg := msg.Message.GetExtendedTextMessage()
+	if extendedMsg != nil {
+		contextInfo := extendedMsg.GetContextInfo()
+		if contextInfo != nil && contextInfo.GetQuotedMessage() != nil {
+			return contextInfo.GetQuotedMessage().GetVideoMessage()
+		}
+	}
+
+	return nil
+}
+
+// quotedImageMessage - Image message sent directly or quoted in a reply
+func (bot *WhatsAppBot) quotedImageMessage(msg *events.Message) *waProto.ImageMessage {
+	if imageMsg := msg.Message.GetImageMessage(); imageMsg != nil {
+		return imageMsg
+	}
+	return msg.Message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage().GetImageMessage()
+}
+
+// quotedAudioMessage - Audio/voice note message sent directly or quoted in a reply
+func (bot *WhatsAppBot) quotedAudioMessage(msg *events.Message) *waProto.AudioMessage {
+	if audioMsg := msg.Message.GetAudioMessage(); audioMsg != nil {
+		return audioMsg
+	}
+	return msg.Message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage().GetAudioMessage()
+}
+
 // sendReply - Send reply message with proper context info for group and DM
 func (bot *WhatsAppBot) sendReply(chatJID types.JID, text string, quotedMsgID string, quotedSender types.JID) {
 	fmt.Printf("📤 Sending reply: %s\n", text[:min(50, len(text))]+"...")
@@ -909,13 +836,18 @@ func min(a, b int) int {
 }
 
 func main() {
+	// The bot re-executes itself to apply rlimits before running ffmpeg/ImageMagick
+	if len(os.Args) > 1 && os.Args[1] == sandboxArg {
+		runSandboxChild(os.Args[2:])
+	}
+
 	fmt.Println("🤖 WhatsApp Bot - Animated Sticker Edition")
 	fmt.Println("🎞️ GIF → Animated WebP Sticker Support")
 	fmt.Println("⚡ fast response & WebP sticker handling")
 	fmt.Println("📱 support multiple users simultaneously")
 	fmt.Println("🎯 proper WebP/PNG sticker handling with animation")
 	fmt.Println("📅 calendar info with WIB timezone")
-	fmt.Println("💎 special OksobatSIJA Exclusive support")
+	fmt.Println("💎 per-group policies from config.json")
 	fmt.Println("🔄 new command system: '.' for all, limited '/' legacy")
 	fmt.Println("🎞️ gif2webp + FFmpeg support for best animated stickers")
 	fmt.Println("=============================================")
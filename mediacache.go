@@ -0,0 +1,337 @@
+// mediacache.go - Content-hash cache for converted stickers/images and their uploads
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Upload reuse - WhatsApp keeps uploaded media on its CDN for a while, stay well inside that
+const (
+	uploadCacheTTL        = 24 * time.Hour
+	uploadCacheMaxEntries = 1000
+)
+
+// Cache kinds - what a cached conversion is
+const (
+	cacheKindSticker = "sticker"
+	cacheKindImage   = "toimg" // PNG or MP4 from .toimg/.togif
+	cacheKindEdit    = "edit"  // .edit result, PNG/JPEG or a sticker
+)
+
+// CachedMedia - A converted result as stored in the cache
+type CachedMedia struct {
+	Data     []byte
+	Animated bool   // animated sticker, or MP4 for .toimg
+	Seconds  uint32 // video length for MP4 results
+}
+
+// cachedUpload - Upload result of a file we already sent
+type cachedUpload struct {
+	response   whatsmeow.UploadResponse
+	uploadedAt time.Time
+}
+
+// MediaCacheStats - Snapshot for .stats
+type MediaCacheStats struct {
+	Entries    int
+	Bytes      int64
+	MaxBytes   int64
+	Hits       int64
+	Misses     int64
+	UploadHits int64
+}
+
+// HitRate - Share of lookups served from the cache, in percent
+func (s MediaCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) * 100 / float64(s.Hits+s.Misses)
+}
+
+// MediaCache - Converted files on disk indexed in bot.db, least recently used evicted
+// first once the directory grows past maxBytes. A nil cache is disabled.
+type MediaCache struct {
+	dir      string
+	maxBytes int64
+	store    *BotStore
+
+	mutex   sync.Mutex // serializes writes and eviction
+	uploads map[string]cachedUpload
+
+	hits       atomic.Int64
+	misses     atomic.Int64
+	uploadHits atomic.Int64
+}
+
+// NewMediaCache - Create the cache directory; maxBytes <= 0 disables caching
+func NewMediaCache(dir string, maxBytes int64, store *BotStore) (*MediaCache, error) {
+	if maxBytes <= 0 {
+		fmt.Printf("⚠️ Media cache disabled\n")
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("gagal bikin folder cache: %v", err)
+	}
+
+	cache := &MediaCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		store:    store,
+		uploads:  make(map[string]cachedUpload),
+	}
+	cache.evict()
+	return cache, nil
+}
+
+// mediaCacheKey - Hash of the conversion kind, the source file hash and the options that shape the output
+func mediaCacheKey(kind string, fileSHA256 []byte, params string) string {
+	if len(fileSHA256) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(kind + "\x00" + hex.EncodeToString(fileSHA256) + "\x00" + params))
+	return hex.EncodeToString(sum[:])
+}
+
+// path - File holding a cached result
+func (c *MediaCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// Get - Cached result for key, refreshing its LRU position
+func (c *MediaCache) Get(key string) (CachedMedia, bool) {
+	if c == nil || key == "" {
+		return CachedMedia{}, false
+	}
+
+	animated, seconds, found, err := c.store.TouchMediaCacheEntry(key, time.Now())
+	if err != nil {
+		fmt.Printf("⚠️ Media cache lookup failed: %v\n", err)
+	}
+	if !found {
+		c.misses.Add(1)
+		return CachedMedia{}, false
+	}
+
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		// File vanished behind our back, forget the entry
+		c.store.DeleteMediaCacheEntry(key)
+		c.misses.Add(1)
+		return CachedMedia{}, false
+	}
+
+	c.hits.Add(1)
+	fmt.Printf("♻️ Media cache hit (%d bytes)\n", len(data))
+	return CachedMedia{Data: data, Animated: animated, Seconds: seconds}, true
+}
+
+// Put - Store a converted result, then evict old entries past the size cap
+func (c *MediaCache) Put(key, kind string, media CachedMedia) {
+	if c == nil || key == "" || int64(len(media.Data)) > c.maxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Write then rename so readers never see half a file
+	tempPath := c.path(key + ".tmp")
+	if err := ioutil.WriteFile(tempPath, media.Data, 0644); err != nil {
+		fmt.Printf("⚠️ Failed to write media cache: %v\n", err)
+		return
+	}
+	if err := os.Rename(tempPath, c.path(key)); err != nil {
+		os.Remove(tempPath)
+		fmt.Printf("⚠️ Failed to write media cache: %v\n", err)
+		return
+	}
+
+	err := c.store.PutMediaCacheEntry(key, kind, int64(len(media.Data)), media.Animated, media.Seconds, time.Now())
+	if err != nil {
+		os.Remove(c.path(key))
+		fmt.Printf("⚠️ Failed to index media cache: %v\n", err)
+		return
+	}
+	c.evictLocked()
+}
+
+// evict - Drop least recently used entries until the cache fits maxBytes
+func (c *MediaCache) evict() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.evictLocked()
+}
+
+func (c *MediaCache) evictLocked() {
+	_, total, err := c.store.MediaCacheUsage()
+	if err != nil {
+		fmt.Printf("⚠️ Media cache usage failed: %v\n", err)
+		return
+	}
+
+	for total > c.maxBytes {
+		key, size, err := c.store.OldestMediaCacheEntry()
+		if err != nil {
+			fmt.Printf("⚠️ Media cache eviction failed: %v\n", err)
+			return
+		}
+		os.Remove(c.path(key))
+		if err := c.store.DeleteMediaCacheEntry(key); err != nil {
+			fmt.Printf("⚠️ Media cache eviction failed: %v\n", err)
+			return
+		}
+		total -= size
+	}
+}
+
+// uploadKey - Uploads are encrypted per media type, so the type is part of the key
+func uploadKey(data []byte, mediaType whatsmeow.MediaType) string {
+	sum := sha256.Sum256(data)
+	return string(mediaType) + ":" + hex.EncodeToString(sum[:])
+}
+
+// GetUpload - Earlier upload of exactly these bytes, if still fresh
+func (c *MediaCache) GetUpload(data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, bool) {
+	if c == nil {
+		return whatsmeow.UploadResponse{}, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := uploadKey(data, mediaType)
+	upload, ok := c.uploads[key]
+	if !ok {
+		return whatsmeow.UploadResponse{}, false
+	}
+	if time.Since(upload.uploadedAt) > uploadCacheTTL {
+		delete(c.uploads, key)
+		return whatsmeow.UploadResponse{}, false
+	}
+
+	c.uploadHits.Add(1)
+	fmt.Printf("♻️ Reusing earlier upload (%d bytes)\n", len(data))
+	return upload.response, true
+}
+
+// PutUpload - Remember an upload so identical files can be sent again without uploading
+func (c *MediaCache) PutUpload(data []byte, mediaType whatsmeow.MediaType, response whatsmeow.UploadResponse) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.uploads) >= uploadCacheMaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for key, upload := range c.uploads {
+			if oldestKey == "" || upload.uploadedAt.Before(oldest) {
+				oldestKey, oldest = key, upload.uploadedAt
+			}
+		}
+		delete(c.uploads, oldestKey)
+	}
+	c.uploads[uploadKey(data, mediaType)] = cachedUpload{response: response, uploadedAt: time.Now()}
+}
+
+// Stats - Current size and hit counters
+func (c *MediaCache) Stats() MediaCacheStats {
+	if c == nil {
+		return MediaCacheStats{}
+	}
+
+	entries, total, err := c.store.MediaCacheUsage()
+	if err != nil {
+		fmt.Printf("⚠️ Media cache usage failed: %v\n", err)
+	}
+	return MediaCacheStats{
+		Entries:    entries,
+		Bytes:      total,
+		MaxBytes:   c.maxBytes,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		UploadHits: c.uploadHits.Load(),
+	}
+}
+
+// mediaFileSHA256 - FileSHA256 of the image/video/sticker sent directly or quoted in a reply
+func (bot *WhatsAppBot) mediaFileSHA256(msg *events.Message) []byte {
+	message := msg.Message
+	if message.GetImageMessage() == nil && message.GetVideoMessage() == nil && message.GetStickerMessage() == nil {
+		message = message.GetExtendedTextMessage().GetContextInfo().GetQuotedMessage()
+	}
+
+	switch {
+	case message.GetImageMessage() != nil:
+		return message.GetImageMessage().GetFileSHA256()
+	case message.GetVideoMessage() != nil:
+		return message.GetVideoMessage().GetFileSHA256()
+	case message.GetStickerMessage() != nil:
+		return message.GetStickerMessage().GetFileSHA256()
+	}
+	return nil
+}
+
+// stickerCacheKey - Cache key for a .s conversion; pack metadata is added after the cache
+func (bot *WhatsAppBot) stickerCacheKey(msg *events.Message, opts StickerOptions) string {
+	params := fmt.Sprintf("%s|%d|%s|%s|%s|%d",
+		opts.Describe(), opts.Quality, opts.TopText, opts.BottomText,
+		bot.config.Media.WebPBackend, bot.config.Media.AnimatedStickerMaxKB)
+	return mediaCacheKey(cacheKindSticker, bot.mediaFileSHA256(msg), params)
+}
+
+// TouchMediaCacheEntry - Look up a cache entry and mark it as just used
+func (s *BotStore) TouchMediaCacheEntry(key string, now time.Time) (animated bool, seconds uint32, found bool, err error) {
+	err = s.db.QueryRow(`UPDATE media_cache SET last_used = ? WHERE cache_key = ? RETURNING animated, seconds`,
+		now.UnixNano(), key).Scan(&animated, &seconds)
+	if err == sql.ErrNoRows {
+		return false, 0, false, nil
+	} else if err != nil {
+		return false, 0, false, err
+	}
+	return animated, seconds, true, nil
+}
+
+// PutMediaCacheEntry - Index a cached file
+func (s *BotStore) PutMediaCacheEntry(key, kind string, size int64, animated bool, seconds uint32, now time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO media_cache (cache_key, kind, size, animated, seconds, created_at, last_used)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (cache_key) DO UPDATE SET size = excluded.size, animated = excluded.animated,
+			seconds = excluded.seconds, last_used = excluded.last_used`,
+		key, kind, size, animated, seconds, now.UnixNano(), now.UnixNano())
+	return err
+}
+
+// DeleteMediaCacheEntry - Remove a cache entry from the index
+func (s *BotStore) DeleteMediaCacheEntry(key string) error {
+	_, err := s.db.Exec(`DELETE FROM media_cache WHERE cache_key = ?`, key)
+	return err
+}
+
+// MediaCacheUsage - Number of cached files and their total size
+func (s *BotStore) MediaCacheUsage() (entries int, total int64, err error) {
+	err = s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(size), 0) FROM media_cache`).Scan(&entries, &total)
+	return entries, total, err
+}
+
+// OldestMediaCacheEntry - Least recently used cache entry
+func (s *BotStore) OldestMediaCacheEntry() (key string, size int64, err error) {
+	err = s.db.QueryRow(`SELECT cache_key, size FROM media_cache ORDER BY last_used, created_at LIMIT 1`).
+		Scan(&key, &size)
+	return key, size, err
+}
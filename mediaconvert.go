@@ -0,0 +1,303 @@
+// mediaconvert.go - Media utility commands: animated sticker to video, video to MP3, audio to voice note
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "tovideo",
+		CmdAliases:     []string{"tomp4"},
+		CmdDescription: "konversi stiker animasi ke video MP4",
+		CmdUsage:       ".tovideo (reply stiker animasi)",
+		CmdCooldown:    5 * time.Second,
+		Handler:        toVideoCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "tomp3",
+		CmdAliases:     []string{"toaudio"},
+		CmdDescription: "ambil audio dari video jadi MP3",
+		CmdUsage:       ".tomp3 (reply video atau audio)",
+		CmdCooldown:    10 * time.Second,
+		Handler:        toAudioCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "tovn",
+		CmdAliases:     []string{"toptt"},
+		CmdDescription: "jadiin audio/video sebagai voice note",
+		CmdUsage:       ".tovn (reply audio atau video)",
+		CmdCooldown:    10 * time.Second,
+		Handler:        toAudioCommand,
+	})
+}
+
+// Audio conversion settings - WhatsApp voice notes are mono Opus in OGG
+const (
+	mp3Bitrate         = "128k"
+	voiceNoteBitrate   = "32k"
+	voiceNoteRate      = "48000"
+	waveformBars       = 64   // bars WhatsApp draws under a voice note
+	waveformSampleRate = 8000 // plenty for loudness and duration
+)
+
+func toVideoCommand(ctx *CommandContext) string {
+	if !ctx.Bot.hasQuotedSticker(ctx.Message) {
+		return "reply stiker animasi dulu biar bisa dijadiin video"
+	}
+	prefix := ctx.Policy.PrimaryPrefix()
+	return ctx.Bot.queueMediaCommand(ctx, mediaPriorityGIF, func(jobCtx context.Context) string {
+		return ctx.Bot.ToVideoHandler(jobCtx, ctx.Sender, ctx.Message, prefix)
+	})
+}
+
+func toAudioCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	voiceNote := ctx.Invoked == "tovn"
+
+	if bot.quotedVideoMessage(ctx.Message) == nil && bot.quotedAudioMessage(ctx.Message) == nil {
+		if voiceNote {
+			return "reply audio atau video dulu biar bisa dijadiin voice note"
+		}
+		return "reply video atau audio dulu biar bisa diambil audionya"
+	}
+	return bot.queueMediaCommand(ctx, mediaPriorityVideo, func(jobCtx context.Context) string {
+		return bot.ToAudioHandler(jobCtx, ctx.Sender, ctx.Message, voiceNote)
+	})
+}
+
+// ToVideoHandler - Render an animated sticker as a regular MP4 video (shares the .toimg cache)
+func (bot *WhatsAppBot) ToVideoHandler(ctx context.Context, sender types.JID, msg *events.Message, prefix string) string {
+	fmt.Printf("🎬 PROCESSING: Converting sticker to video for +%s\n", sender.User)
+
+	cacheKey := mediaCacheKey(cacheKindImage, bot.mediaFileSHA256(msg), "")
+	if cached, ok := bot.mediaCache.Get(cacheKey); ok && cached.Animated {
+		return bot.sendConvertedSticker(msg, sender, cached, false)
+	}
+
+	stickerData, err := bot.downloadSticker(ctx, msg)
+	if err != nil {
+		fmt.Printf("❌ Failed to download sticker: %v\n", err)
+		return "yah gagal download stickernya. coba lagi ya"
+	}
+	if !isAnimatedWebP(stickerData) {
+		return "stiker ini ga gerak, pake " + prefix + "toimg aja biar jadi gambar"
+	}
+
+	videoData, seconds, err := bot.convertAnimatedStickerToMP4(ctx, stickerData)
+	if err != nil {
+		fmt.Printf("❌ Failed to convert animated sticker: %v\n", err)
+		return "waduh gagal convert stiker animasi ke video: " + err.Error()
+	}
+
+	converted := CachedMedia{Data: videoData, Animated: true, Seconds: seconds}
+	bot.mediaCache.Put(cacheKey, cacheKindImage, converted)
+	return bot.sendConvertedSticker(msg, sender, converted, false)
+}
+
+// ToAudioHandler - Extract the audio of a video/audio as MP3, or as an Opus voice note
+func (bot *WhatsAppBot) ToAudioHandler(ctx context.Context, sender types.JID, msg *events.Message, voiceNote bool) string {
+	fmt.Printf("🎵 PROCESSING: Converting to audio for +%s (voice note: %v)\n", sender.User, voiceNote)
+
+	if !bot.isToolAvailable("ffmpeg") {
+		return "ffmpeg belum keinstall di server, jadi belum bisa convert audio"
+	}
+
+	mediaData, mediaType, err := bot.downloadMedia(ctx, msg)
+	if err != nil {
+		fmt.Printf("❌ Failed to download media: %v\n", err)
+		return "yah gagal download medianya nih. coba lagi ya"
+	}
+	if mediaType == "gif" {
+		return "GIF ga ada suaranya, reply video atau audio ya"
+	}
+
+	var audioData, waveform []byte
+	var seconds uint32
+	if voiceNote {
+		audioData, seconds, waveform, err = bot.convertToVoiceNote(ctx, mediaData)
+	} else {
+		audioData, seconds, err = bot.convertToMP3(ctx, mediaData)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to convert audio: %v\n", err)
+		if strings.Contains(err.Error(), "does not contain any stream") {
+			return "videonya ga ada suaranya nih"
+		}
+		return "waduh gagal convert audionya: " + err.Error()
+	}
+
+	err = bot.sendAudio(msg.Info.Chat, audioData, seconds, voiceNote, waveform, msg.Info.ID)
+	if err != nil {
+		fmt.Printf("❌ Failed to send audio: %v\n", err)
+		return "yah gagal kirim audionya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Audio sent successfully to +%s\n", sender.User)
+	return ""
+}
+
+// convertToMP3 - Drop the video stream and encode the audio with libmp3lame
+func (bot *WhatsAppBot) convertToMP3(ctx context.Context, mediaData []byte) ([]byte, uint32, error) {
+	if !bot.ffmpegHasEncoder("libmp3lame") {
+		return nil, 0, fmt.Errorf("ffmpeg di server ga ada encoder MP3 (libmp3lame)")
+	}
+
+	tempDir, inputPath, err := writeAudioInput("to_mp3_*", mediaData)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	outputPath := filepath.Join(tempDir, "output.mp3")
+	output, err := bot.runTool(ctx, "ffmpeg",
+		"-i", inputPath,
+		"-vn",
+		"-c:a", "libmp3lame",
+		"-b:a", mp3Bitrate,
+		"-y", outputPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+	}
+
+	audioData, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("gagal baca hasil audio: %v", err)
+	}
+
+	seconds, _, err := bot.analyzeAudio(ctx, outputPath, tempDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fmt.Printf("✅ Audio converted to MP3 (%ds, %d bytes)\n", seconds, len(audioData))
+	return audioData, seconds, nil
+}
+
+// convertToVoiceNote - Mono Opus in OGG plus the waveform WhatsApp shows on voice notes.
+// Falls back to ffmpeg's own (experimental) Opus encoder when libopus is missing.
+func (bot *WhatsAppBot) convertToVoiceNote(ctx context.Context, mediaData []byte) ([]byte, uint32, []byte, error) {
+	tempDir, inputPath, err := writeAudioInput("to_vn_*", mediaData)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	codecArgs := []string{"-c:a", "libopus", "-application", "voip"}
+	if !bot.ffmpegHasEncoder("libopus") {
+		fmt.Printf("⚠️ libopus not found, using ffmpeg's native Opus encoder\n")
+		codecArgs = []string{"-c:a", "opus", "-strict", "-2"}
+	}
+
+	outputPath := filepath.Join(tempDir, "output.ogg")
+	args := []string{"-i", inputPath, "-vn", "-ac", "1", "-ar", voiceNoteRate}
+	args = append(args, codecArgs...)
+	args = append(args, "-b:a", voiceNoteBitrate, "-f", "ogg", "-y", outputPath)
+
+	output, err := bot.runTool(ctx, "ffmpeg", args...)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+	}
+
+	audioData, err := ioutil.ReadFile(outputPath)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("gagal baca hasil audio: %v", err)
+	}
+
+	seconds, waveform, err := bot.analyzeAudio(ctx, outputPath, tempDir)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	fmt.Printf("✅ Audio converted to voice note (%ds, %d bytes)\n", seconds, len(audioData))
+	return audioData, seconds, waveform, nil
+}
+
+// writeAudioInput - Temp dir with the downloaded media saved in it; ffmpeg probes the format itself
+func writeAudioInput(pattern string, mediaData []byte) (string, string, error) {
+	tempDir, err := ioutil.TempDir("", pattern)
+	if err != nil {
+		return "", "", fmt.Errorf("gagal create temp dir: %v", err)
+	}
+
+	inputPath := filepath.Join(tempDir, "input.media")
+	if err := ioutil.WriteFile(inputPath, mediaData, 0644); err != nil {
+		os.RemoveAll(tempDir)
+		return "", "", fmt.Errorf("gagal save input: %v", err)
+	}
+	return tempDir, inputPath, nil
+}
+
+// analyzeAudio - Duration and waveform of an encoded file, from a low-rate mono PCM decode
+func (bot *WhatsAppBot) analyzeAudio(ctx context.Context, audioPath, tempDir string) (uint32, []byte, error) {
+	pcmPath := filepath.Join(tempDir, "waveform.pcm")
+	output, err := bot.runTool(ctx, "ffmpeg",
+		"-i", audioPath,
+		"-ac", "1",
+		"-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le",
+		"-y", pcmPath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+	}
+
+	pcm, err := ioutil.ReadFile(pcmPath)
+	if err != nil {
+		return 0, nil, fmt.Errorf("gagal baca audio: %v", err)
+	}
+
+	samples := len(pcm) / 2
+	seconds := uint32((samples + waveformSampleRate - 1) / waveformSampleRate)
+	if seconds == 0 {
+		seconds = 1
+	}
+	return seconds, audioWaveform(pcm), nil
+}
+
+// audioWaveform - RMS loudness of 64 equal slices of 16-bit PCM, scaled so the loudest is 100
+func audioWaveform(pcm []byte) []byte {
+	samples := len(pcm) / 2
+	waveform := make([]byte, waveformBars)
+	if samples == 0 {
+		return waveform
+	}
+
+	levels := make([]float64, waveformBars)
+	var loudest float64
+	for i := range levels {
+		start := i * samples / waveformBars
+		end := (i + 1) * samples / waveformBars
+		if end <= start {
+			continue
+		}
+
+		var sum float64
+		for j := start; j < end; j++ {
+			sample := float64(int16(binary.LittleEndian.Uint16(pcm[2*j:])))
+			sum += sample * sample
+		}
+		levels[i] = math.Sqrt(sum / float64(end-start))
+		if levels[i] > loudest {
+			loudest = levels[i]
+		}
+	}
+
+	if loudest == 0 {
+		return waveform
+	}
+	for i, level := range levels {
+		waveform[i] = byte(math.Round(level / loudest * 100))
+	}
+	return waveform
+}
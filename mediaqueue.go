@@ -0,0 +1,279 @@
+// mediaqueue.go - Bounded worker pool and priority queue for media conversion jobs
+package main
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Media job priorities - lower runs first
+const (
+	mediaPriorityImage = 0 // static images and stickers, quick to convert
+	mediaPriorityGIF   = 1
+	mediaPriorityVideo = 2 // ffmpeg video jobs, slowest
+)
+
+// MediaJob - A queued media conversion
+type MediaJob struct {
+	Name     string // for logs
+	Sender   string
+	Priority int
+	Run      func()
+
+	senderLoad int // jobs the sender already had queued/running when submitted
+	seq        uint64
+	enqueued   time.Time
+	index      int
+}
+
+// mediaJobHeap - Orders jobs by sender load, then priority, then arrival
+type mediaJobHeap []*MediaJob
+
+func (h mediaJobHeap) Len() int { return len(h) }
+
+func (h mediaJobHeap) Less(i, j int) bool {
+	if h[i].senderLoad != h[j].senderLoad {
+		return h[i].senderLoad < h[j].senderLoad
+	}
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority < h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h mediaJobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *mediaJobHeap) Push(x interface{}) {
+	job := x.(*MediaJob)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *mediaJobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return job
+}
+
+// MediaQueueStats - Snapshot for .stats
+type MediaQueueStats struct {
+	Workers   int
+	Busy      int
+	Queued    int
+	Processed int64
+	AvgWait   time.Duration
+	MaxWait   time.Duration
+}
+
+// MediaQueue - Fixed number of workers pulling from a priority queue
+type MediaQueue struct {
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	jobs       mediaJobHeap
+	workers    int
+	busy       int
+	seq        uint64
+	senderLoad map[string]int
+	stopped    bool
+
+	processed int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// NewMediaQueue - Create a queue served by the given number of workers
+func NewMediaQueue(workers int) *MediaQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &MediaQueue{
+		workers:    workers,
+		senderLoad: make(map[string]int),
+	}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+// Start - Launch the worker goroutines
+func (q *MediaQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		go q.worker(i + 1)
+	}
+	fmt.Printf("🧵 Media queue started with %d workers\n", q.workers)
+}
+
+// Stop - Let workers exit once the queue is drained
+func (q *MediaQueue) Stop() {
+	q.mutex.Lock()
+	q.stopped = true
+	q.mutex.Unlock()
+	q.cond.Broadcast()
+}
+
+// Submit - Queue a job; returns its position among waiting jobs (0 = starts right away)
+func (q *MediaQueue) Submit(job *MediaJob) int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.seq++
+	job.seq = q.seq
+	job.enqueued = time.Now()
+	job.senderLoad = q.senderLoad[job.Sender]
+	q.senderLoad[job.Sender]++
+	heap.Push(&q.jobs, job)
+
+	// Count how many waiting jobs will be picked before this one
+	position := 1
+	for _, other := range q.jobs {
+		if other != job && !q.jobs.Less(job.index, other.index) {
+			position++
+		}
+	}
+
+	idle := q.workers - q.busy
+	q.cond.Signal()
+	if position <= idle {
+		return 0
+	}
+	return position - idle
+}
+
+// Stats - Current queue depth and wait times
+func (q *MediaQueue) Stats() MediaQueueStats {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stats := MediaQueueStats{
+		Workers:   q.workers,
+		Busy:      q.busy,
+		Queued:    len(q.jobs),
+		Processed: q.processed,
+		MaxWait:   q.maxWait,
+	}
+	if q.processed > 0 {
+		stats.AvgWait = q.totalWait / time.Duration(q.processed)
+	}
+	return stats
+}
+
+// runJob - Run one job; a panic (e.g. a decoder choking on crafted media) fails only that job
+func (q *MediaQueue) runJob(id int, job *MediaJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("💥 Worker %d: %s for +%s panicked: %v\n%s", id, job.Name, job.Sender, r, debug.Stack())
+		}
+	}()
+	job.Run()
+}
+
+func (q *MediaQueue) worker(id int) {
+	for {
+		q.mutex.Lock()
+		for len(q.jobs) == 0 && !q.stopped {
+			q.cond.Wait()
+		}
+		if len(q.jobs) == 0 && q.stopped {
+			q.mutex.Unlock()
+			return
+		}
+
+		job := heap.Pop(&q.jobs).(*MediaJob)
+		wait := time.Since(job.enqueued)
+		q.busy++
+		q.processed++
+		q.totalWait += wait
+		if wait > q.maxWait {
+			q.maxWait = wait
+		}
+		q.mutex.Unlock()
+
+		fmt.Printf("🧵 Worker %d: %s for +%s (waited %v)\n", id, job.Name, job.Sender, wait.Truncate(time.Millisecond))
+		q.runJob(id, job)
+
+		q.mutex.Lock()
+		q.busy--
+		if q.senderLoad[job.Sender]--; q.senderLoad[job.Sender] <= 0 {
+			delete(q.senderLoad, job.Sender)
+		}
+		q.mutex.Unlock()
+	}
+}
+
+// mediaPriority - Priority for the media a message carries or quotes
+func (bot *WhatsAppBot) mediaPriority(ctx *CommandContext) int {
+	if videoMsg := bot.quotedVideoMessage(ctx.Message); videoMsg != nil {
+		if videoMsg.GetGifPlayback() || videoMsg.GetMimetype() == "image/gif" {
+			return mediaPriorityGIF
+		}
+		return mediaPriorityVideo
+	}
+	return mediaPriorityImage
+}
+
+// queueMediaCommand - Run a heavy media handler on the worker pool instead of the message
+// goroutine. The handler gets a context bounded by the media timeout and the shutdown
+// context; its text reply (if any) is sent when the job finishes.
+func (bot *WhatsAppBot) queueMediaCommand(ctx *CommandContext, priority int, run func(jobCtx context.Context) string) string {
+	return bot.queueMediaJob(ctx, priority, bot.config.Limits.MediaTimeout(), run)
+}
+
+// queueMediaJob - queueMediaCommand with an explicit deadline, for jobs covering several files
+func (bot *WhatsAppBot) queueMediaJob(ctx *CommandContext, priority int, timeout time.Duration, run func(jobCtx context.Context) string) string {
+	bot.wg.Add(1)
+	position := bot.mediaQueue.Submit(&MediaJob{
+		Name:     ctx.Prefix + ctx.Invoked,
+		Sender:   ctx.Sender.User,
+		Priority: priority,
+		Run: func() {
+			defer bot.wg.Done()
+
+			jobCtx, cancel := context.WithTimeout(bot.rootCtx, timeout)
+			defer cancel()
+			jobCtx, report := withToolReport(jobCtx)
+			defer func() {
+				if r := recover(); r != nil {
+					bot.sendReply(ctx.ChatJID, "waduh error pas proses medianya. coba pake file lain ya", ctx.Message.Info.ID, ctx.Sender)
+					panic(r) // the worker logs it and moves on
+				}
+			}()
+
+			reply := mediaFailureReply(jobCtx, report, run(jobCtx))
+			if reply != "" {
+				bot.sendReply(ctx.ChatJID, reply, ctx.Message.Info.ID, ctx.Sender)
+			}
+		},
+	})
+
+	if position > 0 {
+		fmt.Printf("📥 Media job queued at position %d\n", position)
+		return fmt.Sprintf("⏳ lagi antri nih, posisi %d. tunggu bentar ya", position)
+	}
+	return ""
+}
+
+// mediaFailureReply - A failed job that hit its deadline or a sandbox limit gets a clearer
+// reply than the tool error. Successful jobs (empty reply) stay silent.
+func mediaFailureReply(jobCtx context.Context, report *toolReport, reply string) string {
+	switch {
+	case reply == "":
+		return ""
+	case report.TooComplex():
+		return mediaTooComplexReply
+	case errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+		return "⏱️ kelamaan nih prosesnya, medianya terlalu berat. coba yang lebih kecil/pendek ya"
+	case jobCtx.Err() != nil:
+		return "bot lagi restart, coba kirim lagi bentar ya"
+	}
+	return reply
+}
@@ -0,0 +1,217 @@
+// meme.go - .smeme captioned stickers with an embedded outline font
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "smeme",
+		CmdDescription: "stiker meme dengan teks atas/bawah",
+		CmdUsage:       ".smeme teks atas|teks bawah (reply gambar/gif/video)",
+		CmdExamples:    []string{".smeme ketika bot|langsung jalan", ".smeme |cuma teks bawah"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        stickerMemeCommand,
+	})
+}
+
+// memeMaxTextLen - Longest caption per line group accepted
+const memeMaxTextLen = 80
+
+var (
+	memeFontOnce sync.Once
+	memeFont     *opentype.Font
+	memeFontErr  error
+)
+
+// loadMemeFont - Parse the embedded Go Bold font once
+func loadMemeFont() (*opentype.Font, error) {
+	memeFontOnce.Do(func() {
+		memeFont, memeFontErr = opentype.Parse(gobold.TTF)
+	})
+	return memeFont, memeFontErr
+}
+
+// parseMemeText - "top|bottom"; text without a separator goes to the bottom like classic memes
+func parseMemeText(text string) (top, bottom string) {
+	text = strings.TrimSpace(text)
+	if !strings.Contains(text, "|") {
+		return "", strings.ToUpper(truncateRunes(text, memeMaxTextLen))
+	}
+	parts := strings.SplitN(text, "|", 2)
+	top = strings.ToUpper(truncateRunes(strings.TrimSpace(parts[0]), memeMaxTextLen))
+	bottom = strings.ToUpper(truncateRunes(strings.TrimSpace(parts[1]), memeMaxTextLen))
+	return top, bottom
+}
+
+// drawMemeCaption - White text with a black outline at the top and bottom of the image
+func drawMemeCaption(img *image.NRGBA, top, bottom string) {
+	fontData, err := loadMemeFont()
+	if err != nil {
+		fmt.Printf("⚠️ Failed to load meme font: %v\n", err)
+		return
+	}
+
+	bounds := img.Bounds()
+	if top != "" {
+		drawCaptionBlock(img, fontData, top, bounds.Min.Y, true)
+	}
+	if bottom != "" {
+		drawCaptionBlock(img, fontData, bottom, bounds.Max.Y, false)
+	}
+}
+
+// drawCaptionBlock - Wrap text to the width, shrinking the font until it fits in three lines.
+// anchorY is the top edge for top text and the bottom edge for bottom text.
+func drawCaptionBlock(img *image.NRGBA, fontData *opentype.Font, text string, anchorY int, fromTop bool) {
+	width := img.Bounds().Dx()
+	margin := width / 32
+
+	face, lines, err := fitTextFace(fontData, text, width-2*margin, 3, 0, float64(width)/7, float64(width)/20)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to create font face: %v\n", err)
+		return
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	outline := max(2, lineHeight/14)
+
+	y := anchorY + margin + metrics.Ascent.Ceil()
+	if !fromTop {
+		y = anchorY - margin - metrics.Descent.Ceil() - lineHeight*(len(lines)-1)
+	}
+
+	for _, line := range lines {
+		lineWidth := font.MeasureString(face, line).Ceil()
+		x := img.Bounds().Min.X + (width-lineWidth)/2
+		drawOutlinedText(img, face, line, x, y, outline, image.White, image.Black)
+		y += lineHeight
+	}
+}
+
+// fitTextFace - Largest face from maxSize down to minSize whose wrapped lines fit maxWidth,
+// maxLines and maxHeight (0 = no limit). Falls back to the smallest size.
+func fitTextFace(fontData *opentype.Font, text string, maxWidth, maxLines, maxHeight int, maxSize, minSize float64) (font.Face, []string, error) {
+	var face font.Face
+	var lines []string
+	for size := maxSize; size >= minSize; size -= 2 {
+		candidate, err := opentype.NewFace(fontData, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			if face != nil {
+				face.Close()
+			}
+			return nil, nil, err
+		}
+		wrapped := wrapCaption(candidate, text, maxWidth)
+		if face != nil {
+			face.Close()
+		}
+		face, lines = candidate, wrapped
+
+		metrics := face.Metrics()
+		height := (metrics.Ascent + metrics.Descent).Ceil() * len(lines)
+		if (maxLines == 0 || len(lines) <= maxLines) &&
+			(maxHeight == 0 || height <= maxHeight) &&
+			widestLine(face, lines) <= maxWidth {
+			break
+		}
+	}
+	if face == nil {
+		return nil, nil, fmt.Errorf("ukuran font tidak valid")
+	}
+	return face, lines, nil
+}
+
+// wrapCaption - Greedy word wrap to maxWidth pixels
+func wrapCaption(face font.Face, text string, maxWidth int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && font.MeasureString(face, candidate).Ceil() > maxWidth {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current = candidate
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// widestLine - Width in pixels of the longest line
+func widestLine(face font.Face, lines []string) int {
+	widest := 0
+	for _, line := range lines {
+		if w := font.MeasureString(face, line).Ceil(); w > widest {
+			widest = w
+		}
+	}
+	return widest
+}
+
+// drawOutlinedText - Text stamped around the position in the stroke color, then the fill on top (Impact style)
+func drawOutlinedText(img *image.NRGBA, face font.Face, text string, x, y, outline int, fill, stroke image.Image) {
+	drawer := &font.Drawer{Dst: img, Src: stroke, Face: face}
+	for dy := -outline; dy <= outline; dy++ {
+		for dx := -outline; dx <= outline; dx++ {
+			if dx*dx+dy*dy > outline*outline {
+				continue
+			}
+			drawer.Dot = fixed.P(x+dx, y+dy)
+			drawer.DrawString(text)
+		}
+	}
+
+	drawer.Src = fill
+	drawer.Dot = fixed.P(x, y)
+	drawer.DrawString(text)
+}
+
+// memeCaptionImage - Caption alone on a transparent canvas, overlaid on animated stickers
+func memeCaptionImage(size int, top, bottom string) *image.NRGBA {
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	drawMemeCaption(canvas, top, bottom)
+	return canvas
+}
+
+func stickerMemeCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	top, bottom := parseMemeText(ctx.RawArgs())
+	if top == "" && bottom == "" {
+		return fmt.Sprintf("format: %ssmeme teks atas|teks bawah (sambil reply gambar/gif/video)", prefix)
+	}
+	if !bot.hasQuotedImage(ctx.Message) {
+		return "reply gambar, gif, atau video dulu dong biar bisa dijadiin stiker meme"
+	}
+
+	opts := StickerOptions{
+		Layout:     stickerLayoutFit,
+		TopText:    top,
+		BottomText: bottom,
+		Meta:       bot.stickerMetadataFor(ctx.Sender.User),
+	}
+	return bot.queueMediaCommand(ctx, bot.mediaPriority(ctx), func(jobCtx context.Context) string {
+		return bot.StickerHandler(jobCtx, ctx.Sender, ctx.Message, opts)
+	})
+}
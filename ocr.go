@@ -0,0 +1,310 @@
+// ocr.go - .ocr text extraction with a locally installed tesseract
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	xdraw "golang.org/x/image/draw"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "ocr",
+		CmdDescription: "ambil teks dari gambar (bahasa indonesia/inggris)",
+		CmdUsage:       ".ocr [id|en|id+en] (reply gambar)",
+		CmdExamples:    []string{"reply foto dokumen lalu ketik .ocr", ".ocr en", ".ocr id"},
+		CmdCooldown:    10 * time.Second,
+		Handler:        ocrCommand,
+	})
+}
+
+// OCR settings - tesseract reads best around 300 DPI, so small text gets upscaled first
+const (
+	ocrDefaultLanguage = "ind+eng"
+	ocrMinSide         = 1200
+	ocrMaxSide         = 4000
+	ocrMaxUpscale      = 3.0
+	ocrMaxReplyLen     = 4000
+)
+
+// ocrLanguageAliases - What users may type, mapped to tesseract language codes
+var ocrLanguageAliases = map[string]string{
+	"id":        "ind",
+	"ind":       "ind",
+	"indo":      "ind",
+	"indonesia": "ind",
+	"en":        "eng",
+	"eng":       "eng",
+	"english":   "eng",
+	"inggris":   "eng",
+}
+
+func ocrCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	language, explicit := ocrDefaultLanguage, len(ctx.Args) > 0
+	if explicit {
+		var err error
+		if language, err = parseOCRLanguage(ctx.Args[0]); err != nil {
+			return fmt.Sprintf("%s. contoh: %socr id, %socr en, %socr id+en", err.Error(), prefix, prefix, prefix)
+		}
+	}
+	if !bot.isToolAvailable("tesseract") {
+		return "OCR belum tersedia, tesseract belum keinstall di server. cek pake " + prefix + "tools"
+	}
+	if bot.quotedImageMessage(ctx.Message) == nil && !bot.hasQuotedSticker(ctx.Message) {
+		return "reply gambar yang ada teksnya dulu ya"
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityGIF, func(jobCtx context.Context) string {
+		return bot.OCRHandler(jobCtx, ctx.Sender, ctx.Message, language, explicit)
+	})
+}
+
+// OCRHandler - Pre-process the quoted image in Go and let tesseract read it
+func (bot *WhatsAppBot) OCRHandler(ctx context.Context, sender types.JID, msg *events.Message, language string, explicit bool) string {
+	fmt.Printf("🔤 PROCESSING: OCR for +%s (%s)\n", sender.User, language)
+
+	installed, err := bot.ocrLanguages(ctx)
+	if err != nil {
+		fmt.Printf("❌ Failed to list tesseract languages: %v\n", err)
+		return "waduh tesseract-nya error: " + err.Error()
+	}
+	language, err = resolveOCRLanguage(language, explicit, installed)
+	if err != nil {
+		return err.Error()
+	}
+
+	var data []byte
+	if bot.quotedImageMessage(msg) != nil {
+		data, _, err = bot.downloadMedia(ctx, msg)
+	} else {
+		data, err = bot.downloadSticker(ctx, msg)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to download image: %v\n", err)
+		return "yah gagal download gambarnya. coba lagi ya"
+	}
+
+	img, _, err := decodeStillImage(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to decode image: %v\n", err)
+		return "format gambarnya ga didukung nih"
+	}
+
+	text, err := bot.runOCR(ctx, prepareOCRImage(img), language)
+	if err != nil {
+		fmt.Printf("❌ OCR failed: %v\n", err)
+		return "waduh gagal baca teksnya: " + err.Error()
+	}
+	if text == "" {
+		return "ga nemu teks di gambarnya. coba foto yang lebih jelas dan lurus ya"
+	}
+
+	fmt.Printf("✅ OCR done for +%s (%d chars)\n", sender.User, len(text))
+	reply := truncateRunes(text, ocrMaxReplyLen)
+	if reply != text {
+		reply += "\n\n(kepotong, teksnya kepanjangan)"
+	}
+	return fmt.Sprintf("📝 *hasil OCR* (%s):\n\n%s", language, reply)
+}
+
+// runOCR - Save the prepared image and read tesseract's text output file; writing to a file
+// keeps tesseract's stderr chatter out of the result
+func (bot *WhatsAppBot) runOCR(ctx context.Context, img image.Image, language string) (string, error) {
+	tempDir, err := ioutil.TempDir("", "ocr_*")
+	if err != nil {
+		return "", fmt.Errorf("gagal create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("gagal encode gambar: %v", err)
+	}
+	inputPath := filepath.Join(tempDir, "input.png")
+	if err := ioutil.WriteFile(inputPath, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("gagal save gambar: %v", err)
+	}
+
+	outputBase := filepath.Join(tempDir, "output")
+	output, err := bot.runTool(ctx, "tesseract", inputPath, outputBase, "-l", language)
+	if err != nil {
+		return "", fmt.Errorf("tesseract failed: %v, output: %s", err, string(output))
+	}
+
+	text, err := ioutil.ReadFile(outputBase + ".txt")
+	if err != nil {
+		return "", fmt.Errorf("gagal baca hasil OCR: %v", err)
+	}
+	return cleanOCRText(string(text)), nil
+}
+
+// ocrLanguages - Language packs tesseract has installed ("osd" is layout data, not a language)
+func (bot *WhatsAppBot) ocrLanguages(ctx context.Context) ([]string, error) {
+	output, err := bot.runTool(ctx, "tesseract", "--list-langs")
+	if err != nil {
+		return nil, fmt.Errorf("%v, output: %s", err, string(output))
+	}
+
+	var languages []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "osd" || strings.Contains(line, " ") {
+			continue // header line, blank lines
+		}
+		languages = append(languages, line)
+	}
+	return languages, nil
+}
+
+// ocrStatus - One-line OCR readiness for .tools and the startup check
+func (bot *WhatsAppBot) ocrStatus() (bool, string) {
+	if !bot.isToolAvailable("tesseract") {
+		return false, "tesseract not found"
+	}
+
+	ctx, cancel := context.WithTimeout(bot.rootCtx, 10*time.Second)
+	defer cancel()
+	installed, err := bot.ocrLanguages(ctx)
+	if err != nil {
+		return false, "tesseract error: " + err.Error()
+	}
+	if _, err := resolveOCRLanguage(ocrDefaultLanguage, true, installed); err != nil {
+		if _, err := resolveOCRLanguage(ocrDefaultLanguage, false, installed); err != nil {
+			return false, "tesseract installed, but no ind/eng language data"
+		}
+		return true, "tesseract (languages: " + strings.Join(installed, ", ") + ", install tesseract-ocr-ind/eng for both)"
+	}
+	return true, "tesseract (languages: " + strings.Join(installed, ", ") + ")"
+}
+
+// parseOCRLanguage - "id", "en", "id+en"... into tesseract codes
+func parseOCRLanguage(arg string) (string, error) {
+	var codes []string
+	for _, part := range strings.Split(strings.ToLower(arg), "+") {
+		code, ok := ocrLanguageAliases[strings.TrimSpace(part)]
+		if !ok {
+			return "", fmt.Errorf("bahasa %q ga didukung, pilih id atau en", part)
+		}
+		if !strings.Contains(strings.Join(codes, "+"), code) {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, "+"), nil
+}
+
+// resolveOCRLanguage - Check the languages against installed packs. The default quietly
+// drops missing ones; an explicit request must be fully installed.
+func resolveOCRLanguage(language string, explicit bool, installed []string) (string, error) {
+	var available, missing []string
+	for _, code := range strings.Split(language, "+") {
+		found := false
+		for _, have := range installed {
+			if have == code {
+				found = true
+				break
+			}
+		}
+		if found {
+			available = append(available, code)
+		} else {
+			missing = append(missing, code)
+		}
+	}
+
+	if len(available) == 0 || (explicit && len(missing) > 0) {
+		return "", fmt.Errorf("data bahasa %s belum keinstall di tesseract (ada: %s)",
+			strings.Join(missing, ", "), strings.Join(installed, ", "))
+	}
+	return strings.Join(available, "+"), nil
+}
+
+// prepareOCRImage - Grayscale on white, upscale small images and stretch the contrast
+func prepareOCRImage(img image.Image) *image.NRGBA {
+	gray := grayscaleImage(toNRGBA(flattenImage(img)), 0)
+
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	short, long := min(width, height), max(width, height)
+	scale := 1.0
+	if short < ocrMinSide {
+		scale = float64(ocrMinSide) / float64(short)
+		if scale > ocrMaxUpscale {
+			scale = ocrMaxUpscale
+		}
+	}
+	if float64(long)*scale > ocrMaxSide {
+		scale = float64(ocrMaxSide) / float64(long)
+	}
+
+	if scale != 1 {
+		scaled := image.NewNRGBA(image.Rect(0, 0, max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))))
+		xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), gray, gray.Bounds(), xdraw.Src, nil)
+		fmt.Printf("📐 OCR input scaled %dx%d -> %dx%d\n", width, height, scaled.Rect.Dx(), scaled.Rect.Dy())
+		gray = scaled
+	}
+
+	stretchContrast(gray)
+	return gray
+}
+
+// stretchContrast - Map the 1st-99th percentile of a grayscale image onto the full range
+func stretchContrast(img *image.NRGBA) {
+	var histogram [256]int
+	for i := 0; i < len(img.Pix); i += 4 {
+		histogram[img.Pix[i]]++
+	}
+
+	pixels := len(img.Pix) / 4
+	low, high := 0, 255
+	for count := 0; low < 255 && count+histogram[low] <= pixels/100; low++ {
+		count += histogram[low]
+	}
+	for count := 0; high > 0 && count+histogram[high] <= pixels/100; high-- {
+		count += histogram[high]
+	}
+	if high <= low {
+		return
+	}
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		v := (int(img.Pix[i]) - low) * 255 / (high - low)
+		if v < 0 {
+			v = 0
+		} else if v > 255 {
+			v = 255
+		}
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = uint8(v), uint8(v), uint8(v)
+	}
+}
+
+// cleanOCRText - Trim trailing spaces and collapse runs of blank lines
+func cleanOCRText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\f", ""), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
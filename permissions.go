@@ -0,0 +1,254 @@
+// permissions.go - Role based permissions (owner, bot admin, group admin, member, banned)
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Role - Permission level of a caller; the zero value is a regular member
+type Role int
+
+const (
+	RoleBanned Role = iota - 1
+	RoleMember
+	RoleGroupAdmin
+	RoleBotAdmin
+	RoleOwner
+)
+
+// Label - Indonesian label used in replies
+func (r Role) Label() string {
+	switch r {
+	case RoleBanned:
+		return "banned"
+	case RoleGroupAdmin:
+		return "admin grup"
+	case RoleBotAdmin:
+		return "admin bot"
+	case RoleOwner:
+		return "owner bot"
+	}
+	return "member"
+}
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "ban",
+		CmdDescription: "blokir user dari semua command bot",
+		CmdUsage:       ".ban <nomor|@mention|reply> [alasan]",
+		CmdExamples:    []string{".ban 6281234567890 spam", "reply pesan lalu ketik .ban"},
+		MinimumRole:    RoleOwner,
+		Handler:        banCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "unban",
+		CmdDescription: "buka blokir user",
+		CmdUsage:       ".unban <nomor|@mention|reply>",
+		CmdExamples:    []string{".unban 6281234567890"},
+		MinimumRole:    RoleOwner,
+		Handler:        unbanCommand,
+	})
+}
+
+// normalizeUserID - "+62 812-3456" / "62812@s.whatsapp.net" -> "62812..."
+func normalizeUserID(id string) string {
+	id = strings.TrimSpace(id)
+	if at := strings.Index(id, "@"); at >= 0 {
+		id = id[:at]
+	}
+	if colon := strings.Index(id, ":"); colon >= 0 {
+		id = id[:colon] // strip device part
+	}
+	return strings.NewReplacer("+", "", "-", "", " ", "").Replace(id)
+}
+
+// senderIDs - Every user ID the sender is known by (phone number and LID)
+func senderIDs(msg *events.Message) []string {
+	ids := []string{msg.Info.Sender.User}
+	if !msg.Info.SenderAlt.IsEmpty() {
+		ids = append(ids, msg.Info.SenderAlt.User)
+	}
+	return ids
+}
+
+// matchesAny - Whether any of the sender IDs appears in a config list
+func matchesAny(list []string, ids []string) bool {
+	for _, entry := range list {
+		entry = normalizeUserID(entry)
+		for _, id := range ids {
+			if entry != "" && entry == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// staticRole - Role from config and ban list only (no network calls)
+func (bot *WhatsAppBot) staticRole(msg *events.Message) Role {
+	ids := senderIDs(msg)
+
+	if matchesAny(bot.config.Owners, ids) {
+		return RoleOwner
+	}
+	if matchesAny(bot.config.Admins, ids) {
+		return RoleBotAdmin
+	}
+
+	banned, err := bot.store.IsBanned(ids)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to check ban list: %v\n", err)
+	} else if banned {
+		return RoleBanned
+	}
+
+	return RoleMember
+}
+
+// baseRole - Cached config/ban list role of the sender
+func (ctx *CommandContext) baseRole() Role {
+	if !ctx.baseResolved {
+		ctx.base = ctx.Bot.staticRole(ctx.Message)
+		ctx.baseResolved = true
+	}
+	return ctx.base
+}
+
+// CallerRole - Full role of the sender, resolving group admin status on first use
+func (ctx *CommandContext) CallerRole() Role {
+	if !ctx.roleResolved {
+		ctx.role = ctx.baseRole()
+		if ctx.role == RoleMember && ctx.IsGroup {
+			isAdmin, err := ctx.Bot.isGroupAdmin(ctx.ChatJID, ctx.Sender)
+			if err != nil {
+				fmt.Printf("⚠️ Failed to check group admin: %v\n", err)
+			} else if isAdmin {
+				ctx.role = RoleGroupAdmin
+			}
+		}
+		ctx.roleResolved = true
+	}
+	return ctx.role
+}
+
+// HasRole - Whether the caller meets a minimum role (group info is only fetched when needed)
+func (ctx *CommandContext) HasRole(min Role) bool {
+	if min <= RoleMember {
+		return ctx.baseRole() >= min
+	}
+	return ctx.CallerRole() >= min
+}
+
+// IsBanned - Whether any of the given user IDs is on the ban list
+func (s *BotStore) IsBanned(ids []string) (bool, error) {
+	for _, id := range ids {
+		var user string
+		err := s.db.QueryRow(`SELECT user_id FROM banned_users WHERE user_id = ?`, id).Scan(&user)
+		if err == nil {
+			return true, nil
+		} else if err != sql.ErrNoRows {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// BanUser - Add a user to the ban list
+func (s *BotStore) BanUser(userID, reason, bannedBy string) error {
+	_, err := s.db.Exec(`INSERT INTO banned_users (user_id, reason, banned_by, banned_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET reason = excluded.reason, banned_by = excluded.banned_by, banned_at = excluded.banned_at`,
+		userID, reason, bannedBy, time.Now().Unix())
+	return err
+}
+
+// UnbanUser - Remove a user from the ban list; reports whether they were banned
+func (s *BotStore) UnbanUser(userID string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM banned_users WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// commandTarget - User a moderation command points at: mention, replied message, or number argument.
+// Returns the target user ID and the remaining arguments.
+func (bot *WhatsAppBot) commandTarget(ctx *CommandContext) (string, []string) {
+	if extendedMsg := ctx.Message.Message.GetExtendedTextMessage(); extendedMsg != nil {
+		if contextInfo := extendedMsg.GetContextInfo(); contextInfo != nil {
+			args := ctx.Args
+			if mentioned := contextInfo.GetMentionedJID(); len(mentioned) > 0 {
+				if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+					args = args[1:]
+				}
+				return normalizeUserID(mentioned[0]), args
+			}
+			if participant := contextInfo.GetParticipant(); participant != "" {
+				return normalizeUserID(participant), args
+			}
+		}
+	}
+
+	if len(ctx.Args) > 0 {
+		id := normalizeUserID(ctx.Args[0])
+		if id != "" && strings.Trim(id, "0123456789") == "" {
+			return id, ctx.Args[1:]
+		}
+	}
+
+	return "", ctx.Args
+}
+
+func banCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	target, rest := bot.commandTarget(ctx)
+	if target == "" {
+		return fmt.Sprintf("format: %sban <nomor|@mention|reply> [alasan]", prefix)
+	}
+	if matchesAny(bot.config.Owners, []string{target}) {
+		return "owner bot ga bisa dibanned"
+	}
+
+	reason := strings.Join(rest, " ")
+	if err := bot.store.BanUser(target, reason, ctx.Sender.User); err != nil {
+		fmt.Printf("❌ Failed to ban user: %v\n", err)
+		return "yah gagal banned user. coba lagi ya"
+	}
+
+	fmt.Printf("🔨 Banned +%s (reason: %s)\n", target, reason)
+	return fmt.Sprintf("🔨 +%s sekarang dibanned dari bot", target)
+}
+
+func unbanCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	target, _ := bot.commandTarget(ctx)
+	if target == "" {
+		return fmt.Sprintf("format: %sunban <nomor|@mention|reply>", prefix)
+	}
+
+	removed, err := bot.store.UnbanUser(target)
+	if err != nil {
+		fmt.Printf("❌ Failed to unban user: %v\n", err)
+		return "yah gagal unban user. coba lagi ya"
+	}
+	if !removed {
+		return fmt.Sprintf("+%s ga lagi dibanned kok", target)
+	}
+
+	fmt.Printf("✅ Unbanned +%s\n", target)
+	return fmt.Sprintf("✅ +%s udah bisa pake bot lagi", target)
+}
+
+// permissionDenied - Localized rejection for callers below a command's minimum role
+func permissionDenied(cmd string, min Role) string {
+	return fmt.Sprintf("command %s cuma bisa dipake %s ya", cmd, min.Label())
+}
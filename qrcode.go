@@ -0,0 +1,242 @@
+// qrcode.go - .qr to make a QR code image and .readqr to read one from a photo
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	gozxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"rsc.io/qr"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "qr",
+		CmdDescription: "bikin gambar QR code dari teks/link/wifi",
+		CmdUsage:       ".qr <teks> atau .qr wifi <nama>|<password>[|WPA|WEP|nopass]",
+		CmdExamples:    []string{".qr https://example.com", ".qr wifi KantorLt2|rahasia123", "reply pesan lalu ketik .qr"},
+		CmdCooldown:    3 * time.Second,
+		Handler:        qrCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "readqr",
+		CmdAliases:     []string{"scanqr"},
+		CmdDescription: "baca isi QR code dari gambar",
+		CmdUsage:       ".readqr (reply gambar atau stiker)",
+		CmdCooldown:    3 * time.Second,
+		Handler:        readQRCommand,
+	})
+}
+
+// QR limits - a 600px-ish image scans fine from a phone screen
+const (
+	qrMaxTextLen   = 1000
+	qrTargetPixels = 640
+	qrMinScale     = 4
+	qrReadMaxSide  = 2000
+	qrReadRetry    = 1000 // smaller retry for noisy photos
+)
+
+func qrCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	text := strings.TrimSpace(ctx.RawArgs())
+	if len(ctx.Args) > 0 && strings.EqualFold(ctx.Args[0], "wifi") {
+		payload, err := wifiQRPayload(strings.TrimSpace(strings.Join(ctx.Args[1:], " ")))
+		if err != nil {
+			return fmt.Sprintf("%s. format: %sqr wifi <nama>|<password>[|WPA|WEP|nopass]", err.Error(), prefix)
+		}
+		text = payload
+	}
+	if text == "" {
+		text = strings.TrimSpace(bot.extractQuotedMessageText(ctx.Message))
+	}
+	if text == "" {
+		return fmt.Sprintf("format: %sqr <teks atau link>, atau reply pesan yang mau dijadiin QR", prefix)
+	}
+	if len([]rune(text)) > qrMaxTextLen {
+		return fmt.Sprintf("teksnya kepanjangan buat QR, maksimal %d huruf", qrMaxTextLen)
+	}
+
+	pngData, err := renderQRCode(text)
+	if err != nil {
+		fmt.Printf("❌ Failed to render QR code: %v\n", err)
+		return "waduh gagal bikin QR: " + err.Error()
+	}
+
+	if err := bot.sendImageWithCaption(ctx.ChatJID, pngData, "udah ku jadiin QR nih", ctx.Message.Info.ID); err != nil {
+		fmt.Printf("❌ Failed to send QR code: %v\n", err)
+		return "yah gagal kirim QR-nya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ QR code sent to +%s (%d chars)\n", ctx.Sender.User, len(text))
+	return ""
+}
+
+func readQRCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	if bot.quotedImageMessage(ctx.Message) == nil && !bot.hasQuotedSticker(ctx.Message) {
+		return "reply gambar yang ada QR-nya dulu ya"
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityImage, func(jobCtx context.Context) string {
+		return bot.ReadQRHandler(jobCtx, ctx.Sender, ctx.Message)
+	})
+}
+
+// ReadQRHandler - Decode the first QR code found in the quoted image or sticker
+func (bot *WhatsAppBot) ReadQRHandler(ctx context.Context, sender types.JID, msg *events.Message) string {
+	fmt.Printf("📷 PROCESSING: Reading QR code for +%s\n", sender.User)
+
+	var data []byte
+	var err error
+	if bot.quotedImageMessage(msg) != nil {
+		data, _, err = bot.downloadMedia(ctx, msg)
+	} else {
+		data, err = bot.downloadSticker(ctx, msg)
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to download image: %v\n", err)
+		return "yah gagal download gambarnya. coba lagi ya"
+	}
+
+	img, _, err := decodeStillImage(data)
+	if err != nil {
+		fmt.Printf("❌ Failed to decode image: %v\n", err)
+		return "format gambarnya ga didukung nih"
+	}
+
+	text, err := decodeQRCode(img)
+	if err != nil {
+		fmt.Printf("⚠️ No QR code found: %v\n", err)
+		return "QR-nya ga kebaca. coba foto lebih dekat, lurus, dan ga blur ya"
+	}
+
+	fmt.Printf("✅ QR code read for +%s (%d chars)\n", sender.User, len(text))
+	return describeQRContent(text)
+}
+
+// renderQRCode - PNG with the quiet zone, scaled to roughly qrTargetPixels
+func renderQRCode(text string) ([]byte, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return nil, err
+	}
+	code.Scale = max(qrMinScale, qrTargetPixels/(code.Size+8))
+	return code.PNG(), nil
+}
+
+// decodeQRCode - Flatten transparency (stickers) on white, then try full size and a smaller copy
+func decodeQRCode(img image.Image) (string, error) {
+	reader := gozxingqr.NewQRCodeReader()
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+
+	var lastErr error
+	for _, side := range []int{qrReadMaxSide, qrReadRetry} {
+		bitmap, err := gozxing.NewBinaryBitmapFromImage(flattenImage(limitImageSide(img, side)))
+		if err != nil {
+			return "", err
+		}
+		result, err := reader.Decode(bitmap, hints)
+		if err == nil {
+			return result.GetText(), nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// wifiQRPayload - "name|password|WPA" into the WIFI: format phone cameras understand
+func wifiQRPayload(text string) (string, error) {
+	parts := strings.Split(text, "|")
+	ssid := strings.TrimSpace(parts[0])
+	if ssid == "" {
+		return "", fmt.Errorf("nama wifi belum diisi")
+	}
+
+	password := ""
+	if len(parts) > 1 {
+		password = strings.TrimSpace(parts[1])
+	}
+	security := "WPA"
+	if len(parts) > 2 {
+		security = strings.ToUpper(strings.TrimSpace(parts[2]))
+	}
+	if password == "" {
+		security = "nopass"
+	}
+
+	switch security {
+	case "WPA", "WEP":
+	case "NOPASS":
+		security, password = "nopass", ""
+	case "nopass":
+	default:
+		return "", fmt.Errorf("keamanan wifi harus WPA, WEP, atau nopass")
+	}
+
+	payload := "WIFI:T:" + security + ";S:" + escapeWifiField(ssid) + ";"
+	if password != "" {
+		payload += "P:" + escapeWifiField(password) + ";"
+	}
+	return payload + ";", nil
+}
+
+// escapeWifiField - Backslash the characters the WIFI: format reserves
+func escapeWifiField(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`).Replace(value)
+}
+
+// describeQRContent - Reply text for a decoded QR; Wi-Fi codes are spelled out
+func describeQRContent(text string) string {
+	if !strings.HasPrefix(text, "WIFI:") {
+		return "📷 isi QR-nya:\n" + text
+	}
+
+	fields := parseWifiQR(strings.TrimPrefix(text, "WIFI:"))
+	reply := "📶 *QR wifi*\nnama: " + fields["S"]
+	if password := fields["P"]; password != "" {
+		reply += "\npassword: " + password
+	}
+	if security := fields["T"]; security != "" {
+		reply += "\nkeamanan: " + security
+	}
+	return reply
+}
+
+// parseWifiQR - "T:WPA;S:name;P:pass;;" into its fields, honouring backslash escapes
+func parseWifiQR(body string) map[string]string {
+	fields := make(map[string]string)
+	var current strings.Builder
+	escaped := false
+
+	flush := func() {
+		if key, value, ok := strings.Cut(current.String(), ":"); ok {
+			fields[key] = value
+		}
+		current.Reset()
+	}
+
+	for _, r := range body {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ';':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return fields
+}
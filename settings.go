@@ -0,0 +1,265 @@
+// settings.go - Group admin .settings command and persisted per-group overrides
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "settings",
+		CmdDescription: "atur bot untuk grup ini (admin grup)",
+		CmdUsage:       ".settings [enable|disable <command> | prefix <p> | silent on|off | reset]",
+		CmdExamples: []string{
+			".settings",
+			".settings disable tagall",
+			".settings prefix !",
+			".settings silent on",
+		},
+		IsGroupOnly: true,
+		Handler:     settingsCommand,
+	})
+}
+
+// GroupSettings - Overrides saved by group admins on top of config.json
+type GroupSettings struct {
+	Prefix   string          // "" keeps the configured prefixes
+	Silent   *bool           // nil keeps the configured silent_block
+	Commands map[string]bool // command name -> enabled
+}
+
+// GetGroupSettings - Load the saved overrides for a group
+func (s *BotStore) GetGroupSettings(groupJID string) (*GroupSettings, error) {
+	settings := &GroupSettings{Commands: make(map[string]bool)}
+
+	var silent sql.NullInt64
+	err := s.db.QueryRow(`SELECT prefix, silent FROM group_settings WHERE group_jid = ?`, groupJID).
+		Scan(&settings.Prefix, &silent)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if silent.Valid {
+		value := silent.Int64 != 0
+		settings.Silent = &value
+	}
+
+	rows, err := s.db.Query(`SELECT command, enabled FROM group_command_settings WHERE group_jid = ?`, groupJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var command string
+		var enabled bool
+		if err := rows.Scan(&command, &enabled); err != nil {
+			return nil, err
+		}
+		settings.Commands[command] = enabled
+	}
+
+	return settings, rows.Err()
+}
+
+// SetGroupPrefix - Save the command prefix for a group
+func (s *BotStore) SetGroupPrefix(groupJID, prefix string) error {
+	_, err := s.db.Exec(`INSERT INTO group_settings (group_jid, prefix) VALUES (?, ?)
+		ON CONFLICT (group_jid) DO UPDATE SET prefix = excluded.prefix`, groupJID, prefix)
+	return err
+}
+
+// SetGroupSilent - Save whether blocked commands are ignored silently
+func (s *BotStore) SetGroupSilent(groupJID string, silent bool) error {
+	_, err := s.db.Exec(`INSERT INTO group_settings (group_jid, silent) VALUES (?, ?)
+		ON CONFLICT (group_jid) DO UPDATE SET silent = excluded.silent`, groupJID, silent)
+	return err
+}
+
+// SetGroupCommand - Enable or disable a command for a group
+func (s *BotStore) SetGroupCommand(groupJID, command string, enabled bool) error {
+	_, err := s.db.Exec(`INSERT INTO group_command_settings (group_jid, command, enabled) VALUES (?, ?, ?)
+		ON CONFLICT (group_jid, command) DO UPDATE SET enabled = excluded.enabled`, groupJID, command, enabled)
+	return err
+}
+
+// ResetGroupSettings - Drop every override so the group follows config.json again
+func (s *BotStore) ResetGroupSettings(groupJID string) error {
+	if _, err := s.db.Exec(`DELETE FROM group_settings WHERE group_jid = ?`, groupJID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM group_command_settings WHERE group_jid = ?`, groupJID)
+	return err
+}
+
+// policyFor - Configured policy for a chat with the group admin overrides applied
+func (bot *WhatsAppBot) policyFor(chatJID types.JID) *GroupPolicy {
+	policy := bot.config.PolicyFor(chatJID)
+	if chatJID.Server != types.GroupServer {
+		return policy
+	}
+
+	settings, err := bot.store.GetGroupSettings(chatJID.String())
+	if err != nil {
+		fmt.Printf("⚠️ Failed to load group settings: %v\n", err)
+		return policy
+	}
+
+	if settings.Prefix != "" {
+		policy.Prefixes = []string{settings.Prefix}
+	}
+	if settings.Silent != nil {
+		policy.SilentBlock = *settings.Silent
+	}
+	policy.CommandOverrides = settings.Commands
+	return policy
+}
+
+// isGroupAdmin - Check via group info whether the sender is an admin of the group
+func (bot *WhatsAppBot) isGroupAdmin(chatJID, sender types.JID) (bool, error) {
+	groupInfo, err := bot.client.GetGroupInfo(chatJID)
+	if err != nil {
+		return false, err
+	}
+
+	senderUser := sender.ToNonAD().User
+	for _, participant := range groupInfo.Participants {
+		if participant.JID.User != senderUser &&
+			participant.PhoneNumber.User != senderUser &&
+			participant.LID.User != senderUser {
+			continue
+		}
+		return participant.IsAdmin || participant.IsSuperAdmin, nil
+	}
+
+	return false, nil
+}
+
+// isValidPrefix - Prefixes are 1-3 symbols without letters, digits or spaces
+func isValidPrefix(prefix string) bool {
+	if prefix == "" || len([]rune(prefix)) > 3 {
+		return false
+	}
+	for _, r := range prefix {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func settingsCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	groupJID := ctx.ChatJID.String()
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	if len(ctx.Args) == 0 {
+		return bot.settingsSummary(ctx)
+	}
+
+	if !ctx.HasRole(RoleGroupAdmin) {
+		return "cuma admin grup yang bisa ubah settings bot ya"
+	}
+
+	action := strings.ToLower(ctx.Args[0])
+	switch action {
+	case "enable", "disable":
+		if len(ctx.Args) < 2 {
+			return fmt.Sprintf("format: %ssettings %s <command>", prefix, action)
+		}
+		name := strings.TrimPrefix(strings.ToLower(ctx.Args[1]), prefix)
+		cmd, ok := bot.commands.Lookup(name)
+		if !ok {
+			return fmt.Sprintf("command %s%s ga ada", prefix, name)
+		}
+		if cmd.Name() == "settings" {
+			return "command settings ga bisa dimatiin"
+		}
+		if action == "enable" && !ctx.Policy.ConfigAllowsCommand(cmd.Name()) {
+			return fmt.Sprintf("command %s%s dimatiin sama owner bot, ga bisa diaktifin dari grup", prefix, cmd.Name())
+		}
+		if err := bot.store.SetGroupCommand(groupJID, cmd.Name(), action == "enable"); err != nil {
+			fmt.Printf("❌ Failed to save group command setting: %v\n", err)
+			return "yah gagal simpan settings. coba lagi ya"
+		}
+		if action == "enable" {
+			return fmt.Sprintf("✅ command %s%s sekarang aktif di grup ini", prefix, cmd.Name())
+		}
+		return fmt.Sprintf("🚫 command %s%s sekarang dimatiin di grup ini", prefix, cmd.Name())
+
+	case "prefix":
+		if len(ctx.Args) < 2 || !isValidPrefix(ctx.Args[1]) {
+			return fmt.Sprintf("format: %ssettings prefix <simbol>, contoh %ssettings prefix !", prefix, prefix)
+		}
+		if err := bot.store.SetGroupPrefix(groupJID, ctx.Args[1]); err != nil {
+			fmt.Printf("❌ Failed to save group prefix: %v\n", err)
+			return "yah gagal simpan settings. coba lagi ya"
+		}
+		return fmt.Sprintf("✅ prefix grup ini sekarang '%s', contoh: %shelp", ctx.Args[1], ctx.Args[1])
+
+	case "silent":
+		if len(ctx.Args) < 2 || (ctx.Args[1] != "on" && ctx.Args[1] != "off") {
+			return fmt.Sprintf("format: %ssettings silent on|off", prefix)
+		}
+		silent := ctx.Args[1] == "on"
+		if err := bot.store.SetGroupSilent(groupJID, silent); err != nil {
+			fmt.Printf("❌ Failed to save silent mode: %v\n", err)
+			return "yah gagal simpan settings. coba lagi ya"
+		}
+		if silent {
+			return "🤫 silent mode aktif - command yang diblokir bakal dicuekin"
+		}
+		return "🔔 silent mode mati - command yang diblokir bakal dikasih tau"
+
+	case "reset":
+		if err := bot.store.ResetGroupSettings(groupJID); err != nil {
+			fmt.Printf("❌ Failed to reset group settings: %v\n", err)
+			return "yah gagal reset settings. coba lagi ya"
+		}
+		return "♻️ settings grup ini balik ke default"
+	}
+
+	return fmt.Sprintf("action '%s' ga dikenal. coba %shelp settings", action, prefix)
+}
+
+// settingsSummary - Current effective settings for the group
+func (bot *WhatsAppBot) settingsSummary(ctx *CommandContext) string {
+	policy := ctx.Policy
+	prefix := policy.PrimaryPrefix()
+
+	silent := "off"
+	if policy.SilentBlock {
+		silent = "on"
+	}
+
+	var disabled []string
+	for _, cmd := range bot.commands.Commands() {
+		if !policy.IsCommandEnabled(cmd.Name()) {
+			disabled = append(disabled, prefix+cmd.Name())
+		}
+	}
+	disabledText := "-"
+	if len(disabled) > 0 {
+		disabledText = strings.Join(disabled, ", ")
+	}
+
+	return fmt.Sprintf(`⚙️ *Settings Grup*
+
+🔤 prefix: %s
+🤫 silent mode: %s
+🚫 command mati: %s
+
+admin grup bisa ubah pake:
+%ssettings enable|disable <command>
+%ssettings prefix <simbol>
+%ssettings silent on|off
+%ssettings reset`,
+		strings.Join(policy.Prefixes, " "),
+		silent,
+		disabledText,
+		prefix, prefix, prefix, prefix)
+}
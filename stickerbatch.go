@@ -0,0 +1,226 @@
+// stickerbatch.go - Recent media per chat and batch stickering (.s all, .s caption on an album)
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Recent media limits - enough for one album, short enough to not keep old media around
+const (
+	recentMediaPerChat = 10
+	recentMediaTTL     = 10 * time.Minute
+	albumWaitMax       = 5 * time.Second
+	albumPollInterval  = 500 * time.Millisecond
+)
+
+// recentMedia - An image/video message seen in a chat
+type recentMedia struct {
+	msg     *events.Message
+	albumID string
+	seenAt  time.Time
+}
+
+// albumInfo - Size announced by an album's parent message
+type albumInfo struct {
+	expected int
+	seenAt   time.Time
+}
+
+// RecentMediaBuffer - Last few images/videos per chat, so .s all and albums can be stickered in one go
+type RecentMediaBuffer struct {
+	mutex     sync.Mutex
+	chats     map[string][]recentMedia
+	albums    map[string]albumInfo
+	lastSweep time.Time
+}
+
+// NewRecentMediaBuffer - Create an empty buffer
+func NewRecentMediaBuffer() *RecentMediaBuffer {
+	return &RecentMediaBuffer{
+		chats:     make(map[string][]recentMedia),
+		albums:    make(map[string]albumInfo),
+		lastSweep: time.Now(),
+	}
+}
+
+// Add - Remember an image/video message, or the expected size when it's an album parent
+func (b *RecentMediaBuffer) Add(msg *events.Message) {
+	now := time.Now()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	defer b.sweep(now)
+
+	if album := msg.Message.GetAlbumMessage(); album != nil {
+		expected := int(album.GetExpectedImageCount() + album.GetExpectedVideoCount())
+		b.albums[msg.Info.ID] = albumInfo{expected: expected, seenAt: now}
+		return
+	}
+	if msg.Message.GetImageMessage() == nil && msg.Message.GetVideoMessage() == nil {
+		return
+	}
+
+	chat := msg.Info.Chat.String()
+	items := append(freshRecentMedia(b.chats[chat], now), recentMedia{msg: msg, albumID: albumIDOf(msg), seenAt: now})
+	if len(items) > recentMediaPerChat {
+		items = items[len(items)-recentMediaPerChat:]
+	}
+	b.chats[chat] = items
+}
+
+// Last - Up to n most recent media of a chat, oldest first
+func (b *RecentMediaBuffer) Last(chat types.JID, n int) []*events.Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	items := freshRecentMedia(b.chats[chat.String()], time.Now())
+	if len(items) > n {
+		items = items[len(items)-n:]
+	}
+
+	msgs := make([]*events.Message, 0, len(items))
+	for _, item := range items {
+		msgs = append(msgs, item.msg)
+	}
+	return msgs
+}
+
+// Album - Media of one album seen so far (oldest first) and how many the album announced (0 = unknown)
+func (b *RecentMediaBuffer) Album(chat types.JID, albumID string) ([]*events.Message, int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var msgs []*events.Message
+	for _, item := range freshRecentMedia(b.chats[chat.String()], time.Now()) {
+		if item.albumID == albumID {
+			msgs = append(msgs, item.msg)
+		}
+	}
+	return msgs, b.albums[albumID].expected
+}
+
+// sweep - Drop expired media of idle chats now and then (caller holds the lock)
+func (b *RecentMediaBuffer) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < recentMediaTTL {
+		return
+	}
+	b.lastSweep = now
+
+	for chat, items := range b.chats {
+		if items = freshRecentMedia(items, now); len(items) == 0 {
+			delete(b.chats, chat)
+		} else {
+			b.chats[chat] = items
+		}
+	}
+	for id, album := range b.albums {
+		if now.Sub(album.seenAt) > recentMediaTTL {
+			delete(b.albums, id)
+		}
+	}
+}
+
+// freshRecentMedia - Items still inside the TTL
+func freshRecentMedia(items []recentMedia, now time.Time) []recentMedia {
+	for i, item := range items {
+		if now.Sub(item.seenAt) <= recentMediaTTL {
+			return items[i:]
+		}
+	}
+	return nil
+}
+
+// albumIDOf - ID of the album a media message belongs to, "" if it's not part of one
+func albumIDOf(msg *events.Message) string {
+	association := msg.Message.GetMessageContextInfo().GetMessageAssociation()
+	if association.GetAssociationType() != waE2E.MessageAssociation_MEDIA_ALBUM {
+		return ""
+	}
+	return association.GetParentMessageKey().GetID()
+}
+
+// unwrapAlbumChild - Album items arrive wrapped in an associated child message; unwrap them
+// so captions, downloads and the recent media buffer see the image/video directly
+func unwrapAlbumChild(msg *events.Message) *events.Message {
+	inner := msg.Message.GetAssociatedChildMessage().GetMessage()
+	if inner == nil {
+		return msg
+	}
+
+	unwrapped := *msg
+	unwrapped.Message = inner
+	if inner.MessageContextInfo == nil {
+		unwrapped.Message.MessageContextInfo = msg.Message.GetMessageContextInfo()
+	}
+	return &unwrapped
+}
+
+// waitForAlbum - Give the rest of an album a moment to arrive; stops early once the announced count is in
+func (bot *WhatsAppBot) waitForAlbum(ctx context.Context, chat types.JID, albumID string) []*events.Message {
+	deadline := time.Now().Add(albumWaitMax)
+	for {
+		items, expected := bot.recentMedia.Album(chat, albumID)
+		if (expected > 0 && len(items) >= min(expected, recentMediaPerChat)) || time.Now().After(deadline) {
+			return items
+		}
+
+		select {
+		case <-ctx.Done():
+			return items
+		case <-time.After(albumPollInterval):
+		}
+	}
+}
+
+// StickerBatchHandler - Sticker every media in order, then report failures in one message
+func (bot *WhatsAppBot) StickerBatchHandler(ctx context.Context, sender types.JID, items []*events.Message, opts StickerOptions) string {
+	fmt.Printf("🗂️ PROCESSING: Batch of %d stickers for +%s (%s)\n", len(items), sender.User, opts.Describe())
+
+	var failures []string
+	succeeded := 0
+	for i, item := range items {
+		if ctx.Err() != nil {
+			failures = append(failures, fmt.Sprintf("#%d-%d: ga sempat diproses", i+1, len(items)))
+			break
+		}
+
+		// Every item gets its own deadline and limit report so one heavy video doesn't sink the rest
+		itemCtx, cancel := context.WithTimeout(ctx, bot.config.Limits.MediaTimeout())
+		itemCtx, report := withToolReport(itemCtx)
+		reply := mediaFailureReply(itemCtx, report, bot.StickerHandler(itemCtx, sender, item, opts))
+		cancel()
+
+		if reply != "" {
+			failures = append(failures, fmt.Sprintf("#%d: %s", i+1, reply))
+		} else {
+			succeeded++
+		}
+	}
+
+	if succeeded == len(items) {
+		fmt.Printf("✅ Batch of %d stickers sent to +%s\n", len(items), sender.User)
+		return ""
+	}
+
+	fmt.Printf("⚠️ Batch finished with %d failures\n", len(failures))
+	return fmt.Sprintf("🧾 %d dari %d stiker jadi. yang gagal:\n%s", succeeded, len(items), strings.Join(failures, "\n"))
+}
+
+// queueStickerBatch - One media job for the whole batch, with a deadline per item
+func (bot *WhatsAppBot) queueStickerBatch(ctx *CommandContext, count int, opts StickerOptions, collect func(jobCtx context.Context) []*events.Message) string {
+	timeout := bot.config.Limits.MediaTimeout()*time.Duration(count) + albumWaitMax
+	return bot.queueMediaJob(ctx, mediaPriorityGIF, timeout, func(jobCtx context.Context) string {
+		items := collect(jobCtx)
+		if len(items) == 0 {
+			return "gambar/video albumnya ga ketemu. coba reply satu-satu pake .s ya"
+		}
+		return bot.StickerBatchHandler(jobCtx, ctx.Sender, items, opts)
+	})
+}
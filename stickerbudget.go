@@ -0,0 +1,123 @@
+// stickerbudget.go - Size-budgeted animated sticker encoding shared by GIF and video inputs
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errAttemptSkipped - The encoder can't do anything new for this attempt, try the next one
+var errAttemptSkipped = errors.New("attempt skipped")
+
+// stickerAttempt - One set of encoder settings tried against the size budget
+type stickerAttempt struct {
+	Quality int     // WebP quality 1-100
+	FPS     int     // output frame rate
+	Size    int     // content side in pixels, padded onto the full stickerSize canvas
+	Seconds float64 // output length in seconds, 0 keeps the whole clip
+}
+
+// Describe - Short summary for logs
+func (a stickerAttempt) Describe() string {
+	duration := "full"
+	if a.Seconds > 0 {
+		duration = fmt.Sprintf("%gs", a.Seconds)
+	}
+	return fmt.Sprintf("q%d %dfps %dpx %s", a.Quality, a.FPS, a.Size, duration)
+}
+
+// stickerBudgetLadder - Attempts from best looking to smallest. Quality goes first since it
+// costs the least visually, then frame rate, then resolution and finally duration. Smaller
+// resolutions shrink the content only; the canvas stays stickerSize so WhatsApp lays it out right.
+func stickerBudgetLadder(quality, fps int, seconds float64) []stickerAttempt {
+	steps := []stickerAttempt{
+		{quality, fps, stickerSize, seconds},
+		{min(quality, 60), fps, stickerSize, seconds},
+		{min(quality, 45), fps, stickerSize, seconds},
+		{min(quality, 45), min(fps, 10), stickerSize, seconds},
+		{min(quality, 35), min(fps, 10), 448, seconds},
+		{min(quality, 30), min(fps, 8), 384, trimSeconds(seconds, 6)},
+		{min(quality, 25), min(fps, 8), 320, trimSeconds(seconds, 4)},
+		{min(quality, 20), min(fps, 6), 256, trimSeconds(seconds, 3)},
+	}
+
+	// A low q= from the user makes the first steps identical
+	ladder := steps[:1]
+	for _, step := range steps[1:] {
+		if step != ladder[len(ladder)-1] {
+			ladder = append(ladder, step)
+		}
+	}
+	return ladder
+}
+
+// trimSeconds - Shorter of the current trim and limit (0 means untrimmed)
+func trimSeconds(seconds, limit float64) float64 {
+	if seconds == 0 || seconds > limit {
+		return limit
+	}
+	return seconds
+}
+
+// Tradeoffs - What an attempt gave up compared to the first one, for the user ("" if nothing)
+func (a stickerAttempt) Tradeoffs(first stickerAttempt) string {
+	var parts []string
+	if a.Quality < first.Quality {
+		parts = append(parts, fmt.Sprintf("kualitas %d", a.Quality))
+	}
+	if a.FPS < first.FPS {
+		parts = append(parts, fmt.Sprintf("%d fps", a.FPS))
+	}
+	if a.Size < first.Size {
+		parts = append(parts, fmt.Sprintf("gambar %dpx", a.Size))
+	}
+	if a.Seconds > 0 && (first.Seconds == 0 || a.Seconds < first.Seconds) {
+		parts = append(parts, fmt.Sprintf("dipotong %g detik", a.Seconds))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// encodeWithinBudget - Run attempts in order until the output fits the animated sticker cap and
+// return the attempt that made it. Output far over the cap skips ahead, since one step rarely
+// saves more than a third. The smallest attempt is always tried before giving up.
+func (bot *WhatsAppBot) encodeWithinBudget(ctx context.Context, label string, ladder []stickerAttempt, encode func(ctx context.Context, attempt stickerAttempt) ([]byte, error)) ([]byte, stickerAttempt, error) {
+	budget := bot.config.Media.AnimatedStickerMaxBytes()
+	last := len(ladder) - 1
+
+	for i := 0; i <= last; {
+		attempt := ladder[i]
+		data, err := encode(ctx, attempt)
+		if errors.Is(err, errAttemptSkipped) {
+			i++
+			continue
+		}
+		if err != nil {
+			return nil, stickerAttempt{}, err
+		}
+
+		if len(data) <= budget {
+			fmt.Printf("✅ %s sticker fits on attempt %d/%d (%s): %d KB of %d KB\n",
+				label, i+1, len(ladder), attempt.Describe(), len(data)/1024, budget/1024)
+			return data, attempt, nil
+		}
+
+		ratio := float64(len(data)) / float64(budget)
+		fmt.Printf("⚠️ %s attempt %d/%d (%s) too large: %d KB of %d KB\n",
+			label, i+1, len(ladder), attempt.Describe(), len(data)/1024, budget/1024)
+
+		next := i + 1
+		if ratio > 3 {
+			next = i + 3
+		} else if ratio > 1.6 {
+			next = i + 2
+		}
+		if next > last && i < last {
+			next = last
+		}
+		i = next
+	}
+
+	return nil, stickerAttempt{}, fmt.Errorf("stiker animasi tetap di atas %d KB walau udah dikecilin", budget/1024)
+}
@@ -0,0 +1,448 @@
+// stickerlibrary.go - Personal sticker packs: save stickers by name, resend them and share packs
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "addsticker",
+		CmdAliases:     []string{"savesticker"},
+		CmdDescription: "simpan stiker ke koleksi kamu",
+		CmdUsage:       ".addsticker <nama> (reply stiker)",
+		CmdExamples:    []string{"reply stiker lalu ketik .addsticker kucing"},
+		CmdCooldown:    3 * time.Second,
+		Handler:        addStickerCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "delsticker",
+		CmdDescription: "hapus stiker dari koleksi kamu",
+		CmdUsage:       ".delsticker <nama>",
+		CmdExamples:    []string{".delsticker kucing"},
+		Handler:        deleteStickerCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "stickers",
+		CmdAliases:     []string{"liststicker"},
+		CmdDescription: "lihat koleksi stiker kamu dan yang dibagiin ke kamu",
+		CmdUsage:       ".stickers",
+		Handler:        listStickersCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "st",
+		CmdDescription: "kirim stiker dari koleksi",
+		CmdUsage:       ".st <nama>",
+		CmdExamples:    []string{".st kucing"},
+		CmdCooldown:    2 * time.Second,
+		Handler:        sendSavedStickerCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "sharepack",
+		CmdDescription: "bagiin koleksi stiker kamu ke user lain atau ke grup ini",
+		CmdUsage:       ".sharepack <nomor|@mention|reply|grup>",
+		CmdExamples:    []string{".sharepack @teman", ".sharepack grup"},
+		Handler:        sharePackCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "unsharepack",
+		CmdDescription: "berhenti bagiin koleksi stiker kamu",
+		CmdUsage:       ".unsharepack <nomor|@mention|reply|grup>",
+		CmdExamples:    []string{".unsharepack @teman", ".unsharepack grup"},
+		Handler:        sharePackCommand,
+	})
+}
+
+// Sticker library limits
+const (
+	savedStickerNameMaxLen = 32
+	savedStickersPerUser   = 100
+	savedStickerMaxBytes   = 1024 * 1024
+)
+
+// SavedSticker - A sticker from someone's library
+type SavedSticker struct {
+	OwnerID  string
+	Name     string
+	Data     []byte
+	Animated bool
+}
+
+// normalizeStickerName - Lowercase name with spaces as dashes; "" when it has other symbols or is too long
+func normalizeStickerName(text string) string {
+	name := strings.ToLower(strings.Join(strings.Fields(text), "-"))
+	if name == "" || len([]rune(name)) > savedStickerNameMaxLen {
+		return ""
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return ""
+		}
+	}
+	return name
+}
+
+// stickerOwnerIDs - All IDs the sender's library may be stored under (phone number and LID, as
+// with the ban list), plus the one new stickers and shares are written with: the phone number
+// when the message carries both
+func stickerOwnerIDs(msg *events.Message) (owner string, ids []string) {
+	ids = senderIDs(msg)
+	owner = msg.Info.Sender.User
+	if msg.Info.Sender.Server == types.HiddenUserServer && !msg.Info.SenderAlt.IsEmpty() {
+		owner = msg.Info.SenderAlt.User
+	}
+	return owner, ids
+}
+
+// sqlInArgs - "?, ?, ?" placeholders and the arguments for an IN clause
+func sqlInArgs(values ...string) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+// isGroupShareArg - "grup"/"group" shares with the current group instead of a user
+func isGroupShareArg(args []string) bool {
+	return len(args) > 0 && (strings.EqualFold(args[0], "grup") || strings.EqualFold(args[0], "group"))
+}
+
+func addStickerCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	name := normalizeStickerName(ctx.RawArgs())
+	if name == "" || !bot.hasQuotedSticker(ctx.Message) {
+		return fmt.Sprintf("format: reply stiker lalu ketik %saddsticker <nama>\nnama maksimal %d huruf/angka, boleh pake - atau _",
+			prefix, savedStickerNameMaxLen)
+	}
+
+	owner, ids := stickerOwnerIDs(ctx.Message)
+	count, err := bot.store.CountSavedStickers(ids)
+	if err != nil {
+		fmt.Printf("❌ Failed to count saved stickers: %v\n", err)
+		return "yah gagal simpan stiker. coba lagi ya"
+	}
+	existing, exists, err := bot.store.GetSavedSticker(ids, name)
+	if err != nil {
+		fmt.Printf("❌ Failed to load saved sticker: %v\n", err)
+		return "yah gagal simpan stiker. coba lagi ya"
+	}
+	if !exists && count >= savedStickersPerUser {
+		return fmt.Sprintf("koleksi kamu udah penuh (%d stiker). hapus dulu pake %sdelsticker <nama>", savedStickersPerUser, prefix)
+	}
+	if exists {
+		owner = existing.OwnerID // replace it where it is, even if saved under the other ID
+	}
+
+	return bot.queueMediaCommand(ctx, mediaPriorityImage, func(jobCtx context.Context) string {
+		return bot.AddStickerHandler(jobCtx, ctx, owner, name, exists)
+	})
+}
+
+// AddStickerHandler - Download the quoted sticker and store it under name in the sender's library
+func (bot *WhatsAppBot) AddStickerHandler(jobCtx context.Context, ctx *CommandContext, owner, name string, replacing bool) string {
+	fmt.Printf("🗂️ PROCESSING: Saving sticker %q for +%s\n", name, ctx.Sender.User)
+
+	data, err := bot.downloadSticker(jobCtx, ctx.Message)
+	if err != nil {
+		fmt.Printf("❌ Failed to download sticker: %v\n", err)
+		return "waduh gagal download stikernya. coba lagi deh"
+	}
+	if len(data) > savedStickerMaxBytes {
+		return fmt.Sprintf("stikernya kegedean buat disimpan (maks %d KB)", savedStickerMaxBytes/1024)
+	}
+
+	sticker := SavedSticker{OwnerID: owner, Name: name, Data: data, Animated: isAnimatedWebP(data)}
+	if err := bot.store.SaveSticker(sticker, time.Now()); err != nil {
+		fmt.Printf("❌ Failed to save sticker: %v\n", err)
+		return "yah gagal simpan stiker. coba lagi ya"
+	}
+
+	fmt.Printf("✅ Saved sticker %q for +%s (%d bytes)\n", name, ctx.Sender.User, len(data))
+	prefix := ctx.Policy.PrimaryPrefix()
+	if replacing {
+		return fmt.Sprintf("♻️ stiker *%s* udah diganti. kirim pake %sst %s", name, prefix, name)
+	}
+	return fmt.Sprintf("✅ stiker *%s* disimpan. kirim pake %sst %s", name, prefix, name)
+}
+
+func deleteStickerCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	name := normalizeStickerName(ctx.RawArgs())
+	if name == "" {
+		return fmt.Sprintf("format: %sdelsticker <nama>", prefix)
+	}
+
+	_, ids := stickerOwnerIDs(ctx.Message)
+	removed, err := bot.store.DeleteSavedSticker(ids, name)
+	if err != nil {
+		fmt.Printf("❌ Failed to delete saved sticker: %v\n", err)
+		return "yah gagal hapus stiker. coba lagi ya"
+	}
+	if !removed {
+		return fmt.Sprintf("stiker *%s* ga ada di koleksi kamu", name)
+	}
+
+	fmt.Printf("🗑️ Deleted sticker %q of +%s\n", name, ctx.Sender.User)
+	return fmt.Sprintf("🗑️ stiker *%s* udah dihapus", name)
+}
+
+func listStickersCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	_, ids := stickerOwnerIDs(ctx.Message)
+	own, err := bot.store.ListSavedStickers(ids)
+	if err != nil {
+		fmt.Printf("❌ Failed to list saved stickers: %v\n", err)
+		return "yah gagal ambil koleksi stiker. coba lagi ya"
+	}
+	shared, err := bot.store.ListSharedStickers(ids, ctx.ChatJID.String())
+	if err != nil {
+		fmt.Printf("❌ Failed to list shared stickers: %v\n", err)
+		return "yah gagal ambil koleksi stiker. coba lagi ya"
+	}
+
+	if len(own) == 0 && len(shared) == 0 {
+		return fmt.Sprintf("koleksi stiker kamu masih kosong. reply stiker lalu ketik %saddsticker <nama>", prefix)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🗂️ *stiker kamu* (%d/%d)\n", len(own), savedStickersPerUser)
+	if len(own) == 0 {
+		sb.WriteString("belum ada\n")
+	}
+	for _, sticker := range own {
+		sb.WriteString(savedStickerLine(sticker))
+	}
+
+	// Shared stickers grouped by owner, in the order the store returns them
+	lastOwner := ""
+	for _, sticker := range shared {
+		if sticker.OwnerID != lastOwner {
+			fmt.Fprintf(&sb, "\n🤝 *dari +%s*\n", sticker.OwnerID)
+			lastOwner = sticker.OwnerID
+		}
+		sb.WriteString(savedStickerLine(sticker))
+	}
+
+	fmt.Fprintf(&sb, "\nkirim pake %sst <nama>", prefix)
+	return sb.String()
+}
+
+// savedStickerLine - One list entry, animated ones marked
+func savedStickerLine(sticker SavedSticker) string {
+	if sticker.Animated {
+		return "• " + sticker.Name + " 🎞️\n"
+	}
+	return "• " + sticker.Name + "\n"
+}
+
+func sendSavedStickerCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+
+	name := normalizeStickerName(ctx.RawArgs())
+	if name == "" {
+		return fmt.Sprintf("format: %sst <nama>. lihat koleksinya pake %sstickers", prefix, prefix)
+	}
+
+	_, ids := stickerOwnerIDs(ctx.Message)
+	sticker, found, err := bot.store.FindAccessibleSticker(ids, ctx.ChatJID.String(), name)
+	if err != nil {
+		fmt.Printf("❌ Failed to load saved sticker: %v\n", err)
+		return "yah gagal ambil stiker. coba lagi ya"
+	}
+	if !found {
+		return fmt.Sprintf("stiker *%s* ga ketemu. lihat koleksinya pake %sstickers", name, prefix)
+	}
+
+	if err := bot.sendSticker(ctx.ChatJID, sticker.Data, ctx.Message.Info.ID, sticker.Animated); err != nil {
+		fmt.Printf("❌ Failed to send saved sticker: %v\n", err)
+		return "yah gagal kirim stickernya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Saved sticker %q of +%s sent to %s\n", name, sticker.OwnerID, ctx.ChatJID)
+	return ""
+}
+
+func sharePackCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+	sharing := ctx.Invoked == "sharepack"
+	_, ids := stickerOwnerIDs(ctx.Message)
+
+	var target, label string
+	if isGroupShareArg(ctx.Args) {
+		if !ctx.IsGroup {
+			return "pake grup cuma bisa di dalam grup ya"
+		}
+		target, label = ctx.ChatJID.String(), "grup ini"
+	} else if id, _ := bot.commandTarget(ctx); id != "" {
+		if containsFold(ids, id) {
+			return "itu kan koleksi kamu sendiri"
+		}
+		target, label = id, "+"+id
+	} else {
+		return fmt.Sprintf("format: %s%s <nomor|@mention|reply|grup>", prefix, ctx.Invoked)
+	}
+
+	if !sharing {
+		removed, err := bot.store.UnshareStickerPack(ids, target)
+		if err != nil {
+			fmt.Printf("❌ Failed to unshare sticker pack: %v\n", err)
+			return "yah gagal ubah pengaturan share. coba lagi ya"
+		}
+		if !removed {
+			return fmt.Sprintf("koleksi kamu emang ga dibagiin ke %s", label)
+		}
+		fmt.Printf("🔒 +%s stopped sharing stickers with %s\n", ctx.Sender.User, target)
+		return fmt.Sprintf("🔒 koleksi stiker kamu udah ga dibagiin ke %s", label)
+	}
+
+	if err := bot.store.ShareStickerPack(ids, target, time.Now()); err != nil {
+		fmt.Printf("❌ Failed to share sticker pack: %v\n", err)
+		return "yah gagal ubah pengaturan share. coba lagi ya"
+	}
+	fmt.Printf("🤝 +%s shared stickers with %s\n", ctx.Sender.User, target)
+	return fmt.Sprintf("🤝 koleksi stiker kamu sekarang bisa dipake %s lewat %sst <nama>", label, prefix)
+}
+
+// SaveSticker - Store a sticker in its owner's library, replacing one with the same name
+func (s *BotStore) SaveSticker(sticker SavedSticker, now time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO saved_stickers (owner_id, name, data, animated, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (owner_id, name) DO UPDATE SET data = excluded.data, animated = excluded.animated,
+			created_at = excluded.created_at`,
+		sticker.OwnerID, sticker.Name, sticker.Data, sticker.Animated, now.Unix())
+	return err
+}
+
+// GetSavedSticker - One sticker of a user's own library (under any of their IDs)
+func (s *BotStore) GetSavedSticker(ownerIDs []string, name string) (SavedSticker, bool, error) {
+	in, args := sqlInArgs(ownerIDs...)
+	sticker := SavedSticker{Name: name}
+	err := s.db.QueryRow(`SELECT owner_id, data, animated FROM saved_stickers
+		WHERE owner_id IN (`+in+`) AND name = ? ORDER BY created_at DESC LIMIT 1`, append(args, name)...).
+		Scan(&sticker.OwnerID, &sticker.Data, &sticker.Animated)
+	if err == sql.ErrNoRows {
+		return SavedSticker{}, false, nil
+	} else if err != nil {
+		return SavedSticker{}, false, err
+	}
+	return sticker, true, nil
+}
+
+// FindAccessibleSticker - Sticker by name from the user's own library, else from a pack
+// shared with the user or with the chat; the oldest shared one wins on name clashes
+func (s *BotStore) FindAccessibleSticker(userIDs []string, chatJID, name string) (SavedSticker, bool, error) {
+	own, ownArgs := sqlInArgs(userIDs...)
+	with, withArgs := sqlInArgs(append(append([]string{}, userIDs...), chatJID)...)
+
+	args := append([]interface{}{name}, ownArgs...)
+	args = append(args, withArgs...)
+	args = append(args, ownArgs...)
+
+	sticker := SavedSticker{Name: name}
+	err := s.db.QueryRow(`SELECT owner_id, data, animated FROM saved_stickers
+		WHERE name = ? AND (owner_id IN (`+own+`) OR owner_id IN
+			(SELECT owner_id FROM sticker_shares WHERE shared_with IN (`+with+`)))
+		ORDER BY owner_id IN (`+own+`) DESC, created_at LIMIT 1`,
+		args...).Scan(&sticker.OwnerID, &sticker.Data, &sticker.Animated)
+	if err == sql.ErrNoRows {
+		return SavedSticker{}, false, nil
+	} else if err != nil {
+		return SavedSticker{}, false, err
+	}
+	return sticker, true, nil
+}
+
+// CountSavedStickers - Size of a user's own library
+func (s *BotStore) CountSavedStickers(ownerIDs []string) (int, error) {
+	in, args := sqlInArgs(ownerIDs...)
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM saved_stickers WHERE owner_id IN (`+in+`)`, args...).Scan(&count)
+	return count, err
+}
+
+// ListSavedStickers - Names of a user's own stickers, without the image data
+func (s *BotStore) ListSavedStickers(ownerIDs []string) ([]SavedSticker, error) {
+	in, args := sqlInArgs(ownerIDs...)
+	return s.querySavedStickers(`SELECT owner_id, name, animated FROM saved_stickers
+		WHERE owner_id IN (`+in+`) ORDER BY name`, args...)
+}
+
+// ListSharedStickers - Names of stickers other users shared with the user or the chat
+func (s *BotStore) ListSharedStickers(userIDs []string, chatJID string) ([]SavedSticker, error) {
+	own, ownArgs := sqlInArgs(userIDs...)
+	with, withArgs := sqlInArgs(append(append([]string{}, userIDs...), chatJID)...)
+	return s.querySavedStickers(`SELECT owner_id, name, animated FROM saved_stickers
+		WHERE owner_id NOT IN (`+own+`) AND owner_id IN (SELECT owner_id FROM sticker_shares WHERE shared_with IN (`+with+`))
+		ORDER BY owner_id, name`, append(ownArgs, withArgs...)...)
+}
+
+func (s *BotStore) querySavedStickers(query string, args ...interface{}) ([]SavedSticker, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stickers []SavedSticker
+	for rows.Next() {
+		var sticker SavedSticker
+		if err := rows.Scan(&sticker.OwnerID, &sticker.Name, &sticker.Animated); err != nil {
+			return nil, err
+		}
+		stickers = append(stickers, sticker)
+	}
+	return stickers, rows.Err()
+}
+
+// DeleteSavedSticker - Remove a sticker from a user's library; reports whether it existed
+func (s *BotStore) DeleteSavedSticker(ownerIDs []string, name string) (bool, error) {
+	in, args := sqlInArgs(ownerIDs...)
+	result, err := s.db.Exec(`DELETE FROM saved_stickers WHERE owner_id IN (`+in+`) AND name = ?`, append(args, name)...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ShareStickerPack - Let a user ID or group JID use the owner's stickers, whichever of the
+// owner's IDs they were saved under
+func (s *BotStore) ShareStickerPack(ownerIDs []string, sharedWith string, now time.Time) error {
+	for _, ownerID := range ownerIDs {
+		_, err := s.db.Exec(`INSERT INTO sticker_shares (owner_id, shared_with, created_at) VALUES (?, ?, ?)
+			ON CONFLICT (owner_id, shared_with) DO NOTHING`,
+			ownerID, sharedWith, now.Unix())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnshareStickerPack - Revoke a share; reports whether it existed
+func (s *BotStore) UnshareStickerPack(ownerIDs []string, sharedWith string) (bool, error) {
+	in, args := sqlInArgs(ownerIDs...)
+	result, err := s.db.Exec(`DELETE FROM sticker_shares WHERE owner_id IN (`+in+`) AND shared_with = ?`,
+		append(args, sharedWith)...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
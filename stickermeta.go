@@ -0,0 +1,169 @@
+// stickermeta.go - WhatsApp sticker pack metadata (EXIF) and per-user pack overrides
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// stickerMetaMaxLen - Longest pack name / author accepted from users
+const stickerMetaMaxLen = 60
+
+// StickerMetadata - Pack info WhatsApp shows under a sticker
+type StickerMetadata struct {
+	PackID    string   `json:"sticker-pack-id"`
+	PackName  string   `json:"sticker-pack-name"`
+	Publisher string   `json:"sticker-pack-publisher"`
+	Emojis    []string `json:"emojis,omitempty"`
+}
+
+// stickerMetadataFor - Configured defaults with the sender's saved pack/author applied
+func (bot *WhatsAppBot) stickerMetadataFor(userID string) StickerMetadata {
+	defaults := bot.config.Sticker
+	meta := StickerMetadata{
+		PackName:  defaults.PackName,
+		Publisher: defaults.Publisher,
+		Emojis:    defaults.Emojis,
+	}
+
+	packName, publisher, found, err := bot.store.GetStickerMetadata(userID)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to load sticker metadata: %v\n", err)
+	} else if found {
+		if packName != "" {
+			meta.PackName = packName
+		}
+		if publisher != "" {
+			meta.Publisher = publisher
+		}
+	}
+
+	meta.PackID = stickerPackID(meta.PackName, meta.Publisher)
+	return meta
+}
+
+// saveStickerMetadata - Merge a new pack/author into the saved one; an empty side keeps the old value
+func (bot *WhatsAppBot) saveStickerMetadata(userID, packName, publisher string) error {
+	oldPack, oldPublisher, _, err := bot.store.GetStickerMetadata(userID)
+	if err != nil {
+		return err
+	}
+	if packName == "" {
+		packName = oldPack
+	}
+	if publisher == "" {
+		publisher = oldPublisher
+	}
+	return bot.store.SetStickerMetadata(userID, packName, publisher)
+}
+
+// stickerPackID - Stable ID so stickers with the same pack/author group together in WhatsApp
+func stickerPackID(packName, publisher string) string {
+	sum := sha256.Sum256([]byte(packName + "|" + publisher))
+	return "whatsapp-bot." + hex.EncodeToString(sum[:8])
+}
+
+// parseStickerMetaArg - "pack|author" from the .s arguments; either side may be empty
+func parseStickerMetaArg(text string) (packName, publisher string, ok bool) {
+	if !strings.Contains(text, "|") {
+		return "", "", false
+	}
+	parts := strings.SplitN(text, "|", 2)
+	packName = truncateRunes(strings.TrimSpace(parts[0]), stickerMetaMaxLen)
+	publisher = truncateRunes(strings.TrimSpace(parts[1]), stickerMetaMaxLen)
+	return packName, publisher, packName != "" || publisher != ""
+}
+
+// truncateRunes - Cut a string to at most n characters
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return text
+}
+
+// stickerEXIF - TIFF/EXIF block with the metadata JSON in WhatsApp's private tag 0x5741
+func stickerEXIF(meta StickerMetadata) ([]byte, error) {
+	payload, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00}) // little endian TIFF, IFD at 8
+	binary.Write(&buf, binary.LittleEndian, uint16(1))              // one IFD entry
+	binary.Write(&buf, binary.LittleEndian, uint16(0x5741))         // tag
+	binary.Write(&buf, binary.LittleEndian, uint16(7))              // type UNDEFINED
+	binary.Write(&buf, binary.LittleEndian, uint32(len(payload)))   // count
+	binary.Write(&buf, binary.LittleEndian, uint32(22))             // value offset, right after this entry
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// addStickerMetadata - Embed the pack EXIF into a static or animated WebP sticker
+func addStickerMetadata(webpData []byte, meta StickerMetadata) ([]byte, error) {
+	exif, err := stickerEXIF(meta)
+	if err != nil {
+		return nil, fmt.Errorf("gagal bikin EXIF: %v", err)
+	}
+
+	chunks, err := parseWebPChunks(webpData)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err = toExtendedWebP(chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the header before flipping the EXIF bit, chunk data aliases the input
+	header := append([]byte(nil), chunks[0].Data...)
+	header[0] |= vp8xFlagEXIF
+
+	result := []webpChunk{{FourCC: "VP8X", Data: header}}
+	exifAdded := false
+	for _, chunk := range chunks[1:] {
+		switch chunk.FourCC {
+		case "EXIF":
+			continue // replaced below
+		case "XMP ":
+			if !exifAdded {
+				result = append(result, webpChunk{FourCC: "EXIF", Data: exif})
+				exifAdded = true
+			}
+		}
+		result = append(result, chunk)
+	}
+	if !exifAdded {
+		result = append(result, webpChunk{FourCC: "EXIF", Data: exif})
+	}
+
+	return buildWebP(result), nil
+}
+
+// GetStickerMetadata - Saved pack name/author of a user
+func (s *BotStore) GetStickerMetadata(userID string) (packName, publisher string, found bool, err error) {
+	err = s.db.QueryRow(`SELECT pack_name, publisher FROM sticker_metadata WHERE user_id = ?`, userID).
+		Scan(&packName, &publisher)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	} else if err != nil {
+		return "", "", false, err
+	}
+	return packName, publisher, true, nil
+}
+
+// SetStickerMetadata - Save pack name/author for a user's future stickers
+func (s *BotStore) SetStickerMetadata(userID, packName, publisher string) error {
+	_, err := s.db.Exec(`INSERT INTO sticker_metadata (user_id, pack_name, publisher) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET pack_name = excluded.pack_name, publisher = excluded.publisher`,
+		userID, packName, publisher)
+	return err
+}
@@ -0,0 +1,434 @@
+// stickeroptions.go - .s options (crop, fill, circle, bg=, q=) for image, GIF and video stickers
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Sticker layouts - how the source is fitted into the 512x512 canvas
+const (
+	stickerLayoutFit  = "fit"  // letterbox, keep everything (default)
+	stickerLayoutCrop = "crop" // center square crop
+	stickerLayoutFill = "fill" // stretch to square
+)
+
+// Video clip limits - WhatsApp animated stickers stay short, speed is kept watchable
+const (
+	maxAnimatedStickerSeconds = 10
+	minStickerClip            = 500 * time.Millisecond
+	minStickerSpeed           = 0.25
+	maxStickerSpeed           = 4.0
+	maxClipInputSeconds       = 24 * 60 * 60 // start/length beyond a day is nonsense, and keeps Duration from overflowing
+)
+
+// stickerColors - Named colors accepted by bg=
+var stickerColors = map[string]color.NRGBA{
+	"white":  {255, 255, 255, 255},
+	"black":  {0, 0, 0, 255},
+	"red":    {230, 40, 40, 255},
+	"green":  {40, 180, 70, 255},
+	"blue":   {40, 90, 230, 255},
+	"yellow": {250, 210, 40, 255},
+	"pink":   {250, 130, 180, 255},
+	"purple": {140, 70, 200, 255},
+	"gray":   {128, 128, 128, 255},
+}
+
+// StickerOptions - Options parsed from the .s arguments
+type StickerOptions struct {
+	Layout     string       // fit, crop or fill
+	Circle     bool         // round mask, transparent corners
+	Background *color.NRGBA // nil keeps transparency
+	Quality    int          // 1-100, 0 uses each path's default
+	TopText    string       // meme caption drawn on every frame (.smeme)
+	BottomText string
+	Start      time.Duration // video clip start ("00:12")
+	Duration   time.Duration // video clip length from Start, 0 uses the default
+	Speed      float64       // playback speed factor, 0 means normal
+	Meta       StickerMetadata
+}
+
+// parseStickerOptions - Options come first, "pack|author" last. The pack text starts at the
+// first token with a "|" or, before that, the first token that isn't an option, so pack names
+// like "Tahun 2024" or "crop|Budi" are never read as options. A timestamp sets the clip start
+// and a bare number its length.
+func parseStickerOptions(args []string) (opts StickerOptions, metaText string, err error) {
+	opts.Layout = stickerLayoutFit
+
+	pipe := len(args)
+	for i, arg := range args {
+		if strings.Contains(arg, "|") {
+			pipe = i
+			break
+		}
+	}
+
+	metaStart := pipe
+	for i, arg := range args[:pipe] {
+		matched, optErr := parseStickerOption(&opts, arg)
+		if optErr != nil {
+			return opts, "", optErr
+		}
+		if !matched {
+			if pipe == len(args) {
+				return opts, "", fmt.Errorf("opsi '%s' ga dikenal", arg)
+			}
+			metaStart = i
+			break
+		}
+	}
+	metaText = strings.Join(args[metaStart:], " ")
+
+	// Clamp the clip so the sticker itself stays within WhatsApp's length limit
+	if opts.Duration > 0 {
+		maxClip := time.Duration(float64(maxAnimatedStickerSeconds*time.Second) * opts.speed())
+		if opts.Duration < minStickerClip {
+			opts.Duration = minStickerClip
+		}
+		if opts.Duration > maxClip {
+			opts.Duration = maxClip
+		}
+	}
+	return opts, metaText, nil
+}
+
+// parseStickerOption - Apply one option argument; matched is false when it isn't an option
+func parseStickerOption(opts *StickerOptions, arg string) (matched bool, err error) {
+	lower := strings.ToLower(arg)
+	switch {
+	case lower == stickerLayoutFit || lower == stickerLayoutCrop || lower == stickerLayoutFill:
+		opts.Layout = lower
+	case lower == "circle" || lower == "bulat":
+		opts.Circle = true
+	case strings.HasPrefix(lower, "bg="):
+		bg, ok := parseStickerColor(strings.TrimPrefix(lower, "bg="))
+		if !ok {
+			return true, fmt.Errorf("warna '%s' ga dikenal. pake nama (white, black, red...) atau hex kayak bg=#ff8800", arg[3:])
+		}
+		opts.Background = bg
+	case strings.HasPrefix(lower, "q="):
+		quality, convErr := strconv.Atoi(strings.TrimPrefix(lower, "q="))
+		if convErr != nil || quality < 1 || quality > 100 {
+			return true, fmt.Errorf("kualitas harus angka 1-100, contoh q=60")
+		}
+		opts.Quality = quality
+	case isSpeedArg(lower):
+		speed, ok := parseSpeedArg(lower)
+		if !ok || speed < minStickerSpeed || speed > maxStickerSpeed {
+			return true, fmt.Errorf("speed harus %g-%g, contoh 2x atau 0.5x", minStickerSpeed, maxStickerSpeed)
+		}
+		opts.Speed = speed
+	case strings.Contains(lower, ":"):
+		start, ok := parseClipTimestamp(lower)
+		if !ok {
+			return true, fmt.Errorf("waktu '%s' ga valid, pake mm:ss kayak 00:12", arg)
+		}
+		opts.Start = start
+	case isClipSeconds(lower):
+		seconds, _ := parseClipNumber(strings.TrimSuffix(lower, "s"))
+		opts.Duration = time.Duration(seconds * float64(time.Second))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseClipTimestamp - "ss", "mm:ss" or "hh:mm:ss", seconds may have a fraction
+func parseClipTimestamp(value string) (time.Duration, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	var total float64
+	for i, part := range parts {
+		number, ok := parseClipNumber(part)
+		if !ok || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, false
+		}
+		if i > 0 && number >= 60 {
+			return 0, false
+		}
+		total = total*60 + number
+	}
+	if total > maxClipInputSeconds {
+		return 0, false
+	}
+	return time.Duration(total * float64(time.Second)), true
+}
+
+// formatClipTime - Seconds with millisecond precision for ffmpeg -ss
+func formatClipTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// isSpeedArg - "2x", "x0.5" or "speed=1.5"
+func isSpeedArg(value string) bool {
+	_, ok := parseSpeedArg(value)
+	return ok
+}
+
+// parseSpeedArg - Speed factor from a speed argument
+func parseSpeedArg(value string) (float64, bool) {
+	number := strings.TrimPrefix(value, "speed=")
+	if number == value {
+		number = strings.TrimSuffix(strings.TrimPrefix(value, "x"), "x")
+		if number == value {
+			return 0, false
+		}
+	}
+	speed, err := strconv.ParseFloat(number, 64)
+	return speed, err == nil && !math.IsNaN(speed) && !math.IsInf(speed, 0)
+}
+
+// isClipSeconds - Bare clip length like "3", "2.5" or "4s"
+func isClipSeconds(value string) bool {
+	seconds, ok := parseClipNumber(strings.TrimSuffix(value, "s"))
+	return ok && seconds > 0
+}
+
+// parseClipNumber - Finite, non-negative seconds no larger than maxClipInputSeconds, so the
+// time.Duration conversion can't overflow ("inf", "nan" and "1e30" are rejected)
+func parseClipNumber(value string) (float64, bool) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, number >= 0 && number <= maxClipInputSeconds
+}
+
+// parseStickerColor - Named color, #rgb or #rrggbb; "transparent" gives nil
+func parseStickerColor(value string) (*color.NRGBA, bool) {
+	if value == "transparent" || value == "none" {
+		return nil, true
+	}
+	if named, ok := stickerColors[value]; ok {
+		return &named, true
+	}
+
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, false
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return &color.NRGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}, true
+}
+
+// NeedsFilters - Whether the options change more than the default letterbox
+func (o StickerOptions) NeedsFilters() bool {
+	return o.Layout != stickerLayoutFit || o.Circle || o.Background != nil || o.HasCaption()
+}
+
+// HasCaption - Whether meme text should be drawn
+func (o StickerOptions) HasCaption() bool {
+	return o.TopText != "" || o.BottomText != ""
+}
+
+// HasClip - Whether a start, length or speed was given
+func (o StickerOptions) HasClip() bool {
+	return o.Start > 0 || o.Duration > 0 || o.speed() != 1
+}
+
+// speed - Playback speed factor, 1 when not set
+func (o StickerOptions) speed() float64 {
+	if o.Speed <= 0 {
+		return 1
+	}
+	return o.Speed
+}
+
+// ClipSeconds - Sticker length: the requested clip at the requested speed, capped to the
+// animated sticker limit. Without a clip length it's def (0 = whole input).
+func (o StickerOptions) ClipSeconds(def float64) float64 {
+	if o.Duration <= 0 {
+		return def
+	}
+	return math.Min(o.Duration.Seconds()/o.speed(), maxAnimatedStickerSeconds)
+}
+
+// QualityOr - Requested quality or the path's default
+func (o StickerOptions) QualityOr(def int) int {
+	if o.Quality > 0 {
+		return o.Quality
+	}
+	return def
+}
+
+// Describe - Short summary for logs
+func (o StickerOptions) Describe() string {
+	parts := []string{o.Layout}
+	if o.Circle {
+		parts = append(parts, "circle")
+	}
+	if o.Background != nil {
+		parts = append(parts, "bg="+hexColor(*o.Background))
+	}
+	if o.Quality > 0 {
+		parts = append(parts, fmt.Sprintf("q=%d", o.Quality))
+	}
+	if o.HasCaption() {
+		parts = append(parts, "meme")
+	}
+	if o.Start > 0 {
+		parts = append(parts, "start="+o.Start.String())
+	}
+	if o.Duration > 0 {
+		parts = append(parts, "len="+o.Duration.String())
+	}
+	if o.speed() != 1 {
+		parts = append(parts, fmt.Sprintf("%gx", o.speed()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// hexColor - "#rrggbb"
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// applyStickerLayout - Fit/crop/fill into size x size, then background and circle mask
+func applyStickerLayout(src image.Image, opts StickerOptions, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	srcRect := bounds
+	dstRect := image.Rect(0, 0, size, size)
+
+	switch opts.Layout {
+	case stickerLayoutCrop:
+		side := bounds.Dx()
+		if bounds.Dy() < side {
+			side = bounds.Dy()
+		}
+		x := bounds.Min.X + (bounds.Dx()-side)/2
+		y := bounds.Min.Y + (bounds.Dy()-side)/2
+		srcRect = image.Rect(x, y, x+side, y+side)
+	case stickerLayoutFill:
+		// stretch into the whole canvas
+	default:
+		width, height := size, size
+		if bounds.Dx() > bounds.Dy() {
+			height = max(1, bounds.Dy()*size/bounds.Dx())
+		} else {
+			width = max(1, bounds.Dx()*size/bounds.Dy())
+		}
+		x := (size - width) / 2
+		y := (size - height) / 2
+		dstRect = image.Rect(x, y, x+width, y+height)
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if opts.Background != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(*opts.Background), image.Point{}, draw.Src)
+	}
+	xdraw.CatmullRom.Scale(canvas, dstRect, src, srcRect, xdraw.Over, nil)
+
+	if opts.Circle {
+		applyCircleMask(canvas)
+	}
+	if opts.HasCaption() {
+		drawMemeCaption(canvas, opts.TopText, opts.BottomText)
+	}
+
+	fmt.Printf("✅ Sticker layout %s -> %dx%d\n", opts.Describe(), size, size)
+	return canvas
+}
+
+// applyCircleMask - Make everything outside the inscribed circle transparent (anti-aliased edge)
+func applyCircleMask(img *image.NRGBA) {
+	bounds := img.Bounds()
+	radius := float64(bounds.Dx()) / 2
+	centerX := float64(bounds.Min.X) + radius
+	centerY := float64(bounds.Min.Y) + float64(bounds.Dy())/2
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			distance := math.Hypot(float64(x)+0.5-centerX, float64(y)+0.5-centerY)
+			coverage := radius - distance + 0.5
+			if coverage >= 1 {
+				continue
+			}
+			i := img.PixOffset(x, y)
+			if coverage <= 0 {
+				img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 0, 0, 0, 0
+				continue
+			}
+			img.Pix[i+3] = uint8(float64(img.Pix[i+3]) * coverage)
+		}
+	}
+}
+
+// stickerFilterArgs - ffmpeg arguments for the layout: the caption overlay input (if any),
+// the filter graph and the output mapping. Goes right after the main "-i input". A size below
+// stickerSize is centered on a transparent full-size canvas.
+func stickerFilterArgs(opts StickerOptions, fps, size int, tempDir string) ([]string, error) {
+	var args []string
+	if opts.HasCaption() {
+		captionPath := filepath.Join(tempDir, "caption.png")
+		caption := memeCaptionImage(size, opts.TopText, opts.BottomText)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, caption); err != nil {
+			return nil, fmt.Errorf("gagal encode caption: %v", err)
+		}
+		if err := ioutil.WriteFile(captionPath, buf.Bytes(), 0644); err != nil {
+			return nil, fmt.Errorf("gagal save caption: %v", err)
+		}
+		args = append(args, "-i", captionPath)
+	}
+
+	graph := stickerVideoFilter(opts, fps, size)
+	if size < stickerSize {
+		graph = strings.TrimSuffix(graph, "[out]") +
+			fmt.Sprintf(",pad=%d:%d:-1:-1:color=black@0[out]", stickerSize, stickerSize)
+	}
+	return append(args, "-filter_complex", graph, "-map", "[out]"), nil
+}
+
+// stickerVideoFilter - ffmpeg -filter_complex graph doing the same layout for GIF/video frames.
+// The caption, if any, is input 1 at the same size. The result is labelled [out].
+func stickerVideoFilter(opts StickerOptions, fps, size int) string {
+	var layout string
+	switch opts.Layout {
+	case stickerLayoutCrop:
+		layout = fmt.Sprintf("crop='min(iw,ih)':'min(iw,ih)',scale=%d:%d", size, size)
+	case stickerLayoutFill:
+		layout = fmt.Sprintf("scale=%d:%d", size, size)
+	default:
+		layout = fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:-1:-1:color=black@0", size, size, size, size)
+	}
+
+	graph := fmt.Sprintf("[0:v]fps=%d,format=rgba,%s", fps, layout)
+	if o := opts.speed(); o != 1 {
+		graph = fmt.Sprintf("[0:v]setpts=PTS/%g,fps=%d,format=rgba,%s", o, fps, layout)
+	}
+	if opts.Background != nil {
+		bg := *opts.Background
+		graph = fmt.Sprintf("color=c=0x%02x%02x%02x:s=%dx%d[bg];%s[fg];[bg][fg]overlay=shortest=1,format=rgba",
+			bg.R, bg.G, bg.B, size, size, graph)
+	}
+	if opts.Circle {
+		graph += ",geq=r='r(X,Y)':g='g(X,Y)':b='b(X,Y)':a='if(lte(hypot(X-W/2,Y-H/2),W/2),alpha(X,Y),0)'"
+	}
+	if opts.HasCaption() {
+		graph += "[base];[base][1:v]overlay=0:0:format=auto,format=rgba"
+	}
+	return graph + "[out]"
+}
@@ -0,0 +1,88 @@
+// store.go - SQLite storage for bot data (bot.db next to session.db)
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// botDBPath - Bot data lives in its own SQLite file beside whatsmeow's session.db
+const botDBPath = "file:bot.db?_foreign_keys=on"
+
+// BotStore - Persistent bot settings and data
+type BotStore struct {
+	db *sql.DB
+}
+
+// botSchema - Tables created on startup (idempotent)
+var botSchema = []string{
+	`CREATE TABLE IF NOT EXISTS group_settings (
+		group_jid TEXT PRIMARY KEY,
+		prefix    TEXT NOT NULL DEFAULT '',
+		silent    INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS group_command_settings (
+		group_jid TEXT NOT NULL,
+		command   TEXT NOT NULL,
+		enabled   INTEGER NOT NULL,
+		PRIMARY KEY (group_jid, command)
+	)`,
+	`CREATE TABLE IF NOT EXISTS banned_users (
+		user_id   TEXT PRIMARY KEY,
+		reason    TEXT NOT NULL DEFAULT '',
+		banned_by TEXT NOT NULL DEFAULT '',
+		banned_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS sticker_metadata (
+		user_id   TEXT PRIMARY KEY,
+		pack_name TEXT NOT NULL DEFAULT '',
+		publisher TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS media_cache (
+		cache_key  TEXT PRIMARY KEY,
+		kind       TEXT NOT NULL,
+		size       INTEGER NOT NULL,
+		animated   INTEGER NOT NULL DEFAULT 0,
+		seconds    INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		last_used  INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS media_cache_last_used ON media_cache (last_used)`,
+	`CREATE TABLE IF NOT EXISTS saved_stickers (
+		owner_id   TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		data       BLOB NOT NULL,
+		animated   INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (owner_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS sticker_shares (
+		owner_id    TEXT NOT NULL,
+		shared_with TEXT NOT NULL, -- user ID or group JID
+		created_at  INTEGER NOT NULL,
+		PRIMARY KEY (owner_id, shared_with)
+	)`,
+	`CREATE INDEX IF NOT EXISTS sticker_shares_shared_with ON sticker_shares (shared_with)`,
+}
+
+// OpenBotStore - Open bot.db and make sure the schema exists
+func OpenBotStore(dsn string) (*BotStore, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("gagal buka bot database: %v", err)
+	}
+
+	for _, stmt := range botSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("gagal migrate bot database: %v", err)
+		}
+	}
+
+	return &BotStore{db: db}, nil
+}
+
+// Close - Close the underlying database
+func (s *BotStore) Close() error {
+	return s.db.Close()
+}
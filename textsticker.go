@@ -0,0 +1,175 @@
+// textsticker.go - Text to sticker (.ttp) and animated color cycling text (.attp)
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func init() {
+	registerCommand(&BasicCommand{
+		CmdName:        "ttp",
+		CmdDescription: "bikin stiker dari teks",
+		CmdUsage:       ".ttp <teks> (atau reply pesan)",
+		CmdExamples:    []string{".ttp halo semua", "reply pesan lalu ketik .ttp"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        textStickerCommand,
+	})
+	registerCommand(&BasicCommand{
+		CmdName:        "attp",
+		CmdDescription: "stiker teks animasi warna-warni",
+		CmdUsage:       ".attp <teks> (atau reply pesan)",
+		CmdExamples:    []string{".attp gas terus", "reply pesan lalu ketik .attp"},
+		CmdCooldown:    5 * time.Second,
+		Handler:        textStickerCommand,
+	})
+}
+
+// Text sticker limits
+const (
+	textStickerMaxLen  = 200
+	attpFrameCount     = 12
+	attpFrameDuration  = 100 * time.Millisecond
+	textStickerOutline = 4
+)
+
+// textStickerFill - .ttp text color
+var textStickerFill = color.NRGBA{255, 255, 255, 255}
+
+func textStickerCommand(ctx *CommandContext) string {
+	bot := ctx.Bot
+	prefix := ctx.Policy.PrimaryPrefix()
+	animated := ctx.Invoked == "attp"
+
+	text := strings.TrimSpace(ctx.RawArgs())
+	if text == "" {
+		text = strings.TrimSpace(bot.extractQuotedMessageText(ctx.Message))
+	}
+	if text == "" {
+		return fmt.Sprintf("format: %s%s <teks>, atau reply pesan yang mau dijadiin stiker", prefix, ctx.Invoked)
+	}
+	text = truncateRunes(text, textStickerMaxLen)
+
+	meta := bot.stickerMetadataFor(ctx.Sender.User)
+	priority := mediaPriorityImage
+	if animated {
+		priority = mediaPriorityGIF
+	}
+	return bot.queueMediaCommand(ctx, priority, func(jobCtx context.Context) string {
+		return bot.TextStickerHandler(jobCtx, ctx.Sender, ctx.Message, text, animated, meta)
+	})
+}
+
+// TextStickerHandler - Render text into a static or color cycling sticker and send it
+func (bot *WhatsAppBot) TextStickerHandler(ctx context.Context, sender types.JID, msg *events.Message, text string, animated bool, meta StickerMetadata) string {
+	fmt.Printf("🔤 PROCESSING: Text sticker for +%s (animated: %v)\n", sender.User, animated)
+
+	var stickerData []byte
+	var err error
+	if animated {
+		stickerData, err = bot.renderAnimatedTextSticker(ctx, text)
+	} else {
+		var frame *image.NRGBA
+		frame, err = renderTextSticker(text, textStickerFill)
+		if err == nil {
+			stickerData, err = bot.encodeStaticSticker(ctx, frame, StickerOptions{Layout: stickerLayoutFit})
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ Failed to render text sticker: %v\n", err)
+		return "waduh gagal bikin stiker teks: " + err.Error()
+	}
+	if ctx.Err() != nil {
+		return "waduh gagal bikin stiker teks: " + ctx.Err().Error()
+	}
+
+	err = bot.sendStickerWithMetadata(msg.Info.Chat, stickerData, msg.Info.ID, animated, meta)
+	if err != nil {
+		fmt.Printf("❌ Failed to send text sticker: %v\n", err)
+		return "yah gagal kirim stickernya. coba lagi deh"
+	}
+
+	fmt.Printf("✅ Text sticker sent successfully to +%s\n", sender.User)
+	return ""
+}
+
+// renderTextSticker - Word-wrapped, auto-sized, centered outlined text on a transparent 512x512 canvas
+func renderTextSticker(text string, fill color.NRGBA) (*image.NRGBA, error) {
+	fontData, err := loadMemeFont()
+	if err != nil {
+		return nil, fmt.Errorf("gagal load font: %v", err)
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, stickerSize, stickerSize))
+	margin := stickerSize / 16
+	maxSide := stickerSize - 2*margin
+
+	face, lines, err := fitTextFace(fontData, text, maxSide, 0, maxSide, float64(stickerSize)/4, float64(stickerSize)/24)
+	if err != nil {
+		return nil, fmt.Errorf("gagal bikin font: %v", err)
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	blockHeight := lineHeight * len(lines)
+	y := (stickerSize-blockHeight)/2 + metrics.Ascent.Ceil()
+
+	for _, line := range lines {
+		lineWidth := widestLine(face, []string{line})
+		x := (stickerSize - lineWidth) / 2
+		drawOutlinedText(canvas, face, line, x, y, textStickerOutline, image.NewUniform(fill), image.Black)
+		y += lineHeight
+	}
+	return canvas, nil
+}
+
+// renderAnimatedTextSticker - Same text in a rainbow of colors, one hue per frame, kept under
+// the animated sticker cap
+func (bot *WhatsAppBot) renderAnimatedTextSticker(ctx context.Context, text string) ([]byte, error) {
+	frames := make([]webpFrame, 0, attpFrameCount)
+	for i := 0; i < attpFrameCount; i++ {
+		hue := float64(i) * 360 / attpFrameCount
+		frame, err := renderTextSticker(text, hueColor(hue))
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, webpFrame{Image: frame, Duration: attpFrameDuration})
+	}
+
+	webpData, err := bot.encodeAnimationInBudget(ctx, frames)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("✅ Animated text sticker encoded (%d frames, %d bytes)\n", len(frames), len(webpData))
+	return webpData, nil
+}
+
+// hueColor - Fully saturated color for a hue in degrees
+func hueColor(hue float64) color.NRGBA {
+	sector := int(hue/60) % 6
+	f := hue/60 - float64(int(hue/60))
+	rising := uint8(255 * f)
+	falling := uint8(255 * (1 - f))
+
+	switch sector {
+	case 0:
+		return color.NRGBA{255, rising, 0, 255}
+	case 1:
+		return color.NRGBA{falling, 255, 0, 255}
+	case 2:
+		return color.NRGBA{0, 255, rising, 255}
+	case 3:
+		return color.NRGBA{0, falling, 255, 255}
+	case 4:
+		return color.NRGBA{rising, 0, 255, 255}
+	}
+	return color.NRGBA{255, 0, falling, 255}
+}
@@ -0,0 +1,84 @@
+// toolrunner.go - Context-aware runner for external media tools (ffmpeg, cwebp, convert...)
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errToolTimeout - Returned when a tool was killed because its job deadline passed
+var errToolTimeout = errors.New("tool timed out")
+
+// shutdownGracePeriod - How long Start waits for running jobs before cancelling them
+const shutdownGracePeriod = 30 * time.Second
+
+// toolWaitDelay - How long to wait for output pipes after the process group was killed
+const toolWaitDelay = 2 * time.Second
+
+// runTool - Run an external tool bound to ctx inside the tool sandbox. On cancellation or
+// deadline the whole process group is killed, so helpers spawned by the tool don't linger.
+// Hitting a sandbox limit returns errMediaTooComplex and marks the job.
+func (bot *WhatsAppBot) runTool(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := bot.sandbox.Command(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = toolWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// A tool that finished right as the deadline passed still counts as a success
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			fmt.Printf("⏱️ %s killed: %v\n", name, ctxErr)
+			return output, fmt.Errorf("%s: %w (%v)", name, errToolTimeout, ctxErr)
+		}
+		if reason := bot.sandbox.limitViolation(cmd.ProcessState, output); reason != "" {
+			fmt.Printf("🧱 %s hit sandbox limit: %s\n", name, reason)
+			markMediaTooComplex(ctx)
+			return output, fmt.Errorf("%s: %w (%s)", name, errMediaTooComplex, reason)
+		}
+	}
+	return output, err
+}
+
+// ffmpegHasLibWebP - Whether the installed ffmpeg was built with libwebp, from the startup probe
+func (bot *WhatsAppBot) ffmpegHasLibWebP() bool {
+	return bot.ffmpegHasEncoder("libwebp") || bot.ffmpegHasEncoder("libwebp_anim")
+}
+
+// probeFFmpegEncoders - List ffmpeg's encoders once at startup; .tomp3/.tovn and .tools read
+// the cached set instead of spawning ffmpeg on every call
+func (bot *WhatsAppBot) probeFFmpegEncoders() {
+	encoders := make(map[string]bool)
+	defer func() {
+		bot.mutex.Lock()
+		bot.ffmpegEncoders = encoders
+		bot.mutex.Unlock()
+	}()
+
+	if !bot.isToolAvailable("ffmpeg") {
+		return
+	}
+	ctx, cancel := context.WithTimeout(bot.rootCtx, 10*time.Second)
+	defer cancel()
+
+	output, err := bot.runTool(ctx, "ffmpeg", "-hide_banner", "-encoders")
+	if err != nil {
+		fmt.Printf("⚠️ Failed to list ffmpeg encoders: %v\n", err)
+		return
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			encoders[fields[1]] = true
+		}
+	}
+}
+
+// ffmpegHasEncoder - Whether the installed ffmpeg lists an encoder (libmp3lame, libopus...),
+// as probed at startup
+func (bot *WhatsAppBot) ffmpegHasEncoder(name string) bool {
+	bot.mutex.RLock()
+	defer bot.mutex.RUnlock()
+	return bot.ffmpegEncoders[name]
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+// toolrunner_other.go - Fallback for platforms without process groups
+package main
+
+import "os/exec"
+
+// setProcessGroup - exec.CommandContext already kills the process itself here
+func setProcessGroup(cmd *exec.Cmd) {}
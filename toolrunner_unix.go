@@ -0,0 +1,18 @@
+//go:build unix
+
+// toolrunner_unix.go - Process group handling for tool subprocesses on Unix
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup - Start the tool in its own process group and kill the group on cancel
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// Negative PID signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,161 @@
+// toolsandbox.go - Resource limits, ImageMagick policy and minimal environment for tool subprocesses
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// errMediaTooComplex - Returned when a tool hit a sandbox limit (memory, CPU, output size, policy)
+var errMediaTooComplex = errors.New("media too complex")
+
+// mediaTooComplexReply - What the user sees when a conversion hit a sandbox limit
+const mediaTooComplexReply = "🧩 medianya terlalu berat/kompleks buat diproses bot. coba kirim yang lebih kecil atau lebih pendek ya"
+
+// sandboxArg - First argument that makes the bot binary act as the rlimit wrapper
+const sandboxArg = "__tool_sandbox"
+
+// imageMagickPolicy - Only the formats the bot works with, no delegates, no @file indirection
+const imageMagickPolicy = `<policymap>
+  <policy domain="resource" name="memory" value="%dMiB"/>
+  <policy domain="resource" name="map" value="%dMiB"/>
+  <policy domain="resource" name="disk" value="%dMiB"/>
+  <policy domain="resource" name="time" value="%d"/>
+  <policy domain="resource" name="width" value="16KP"/>
+  <policy domain="resource" name="height" value="16KP"/>
+  <policy domain="resource" name="area" value="128MP"/>
+  <policy domain="delegate" rights="none" pattern="*"/>
+  <policy domain="coder" rights="none" pattern="*"/>
+  <policy domain="coder" rights="read|write" pattern="{GIF,JPEG,JPG,PNG,WEBP}"/>
+  <policy domain="path" rights="none" pattern="@*"/>
+</policymap>
+`
+
+// limitOutputHints - Tool messages that mean a limit or policy was hit rather than a plain failure
+var limitOutputHints = []string{
+	"cannot allocate memory",
+	"memory allocation failed",
+	"out of memory",
+	"bad_alloc",
+	"not authorized",
+	"resource limit",
+	"cache resources exhausted",
+	"exceeds limit",
+	"file size limit exceeded",
+}
+
+// ToolSandbox - Limits applied to every external tool the bot runs
+type ToolSandbox struct {
+	MemoryBytes uint64 // RLIMIT_AS
+	CPUSeconds  uint64 // RLIMIT_CPU
+	FileBytes   uint64 // RLIMIT_FSIZE
+
+	magickDir string   // holds policy.xml, used as MAGICK_CONFIGURE_PATH
+	env       []string // minimal environment passed to tools
+}
+
+// NewToolSandbox - Write the ImageMagick policy and build the tool environment
+func NewToolSandbox(limits LimitConfig) (*ToolSandbox, error) {
+	sandbox := &ToolSandbox{
+		MemoryBytes: uint64(limits.ToolMemoryMB) << 20,
+		CPUSeconds:  uint64(limits.ToolCPUSeconds),
+		FileBytes:   uint64(limits.ToolMaxFileMB) << 20,
+	}
+
+	magickDir, err := ioutil.TempDir("", "bot_magick_*")
+	if err != nil {
+		return nil, fmt.Errorf("gagal create policy dir: %v", err)
+	}
+	policy := fmt.Sprintf(imageMagickPolicy,
+		limits.ToolMemoryMB/4, limits.ToolMemoryMB/2, limits.ToolMaxFileMB*4, limits.ToolCPUSeconds)
+	if err := ioutil.WriteFile(filepath.Join(magickDir, "policy.xml"), []byte(policy), 0644); err != nil {
+		os.RemoveAll(magickDir)
+		return nil, fmt.Errorf("gagal tulis ImageMagick policy: %v", err)
+	}
+	sandbox.magickDir = magickDir
+
+	path := os.Getenv("PATH")
+	if path == "" {
+		path = "/usr/local/bin:/usr/bin:/bin"
+	}
+	sandbox.env = []string{
+		"PATH=" + path,
+		"HOME=" + magickDir,
+		"TMPDIR=" + os.TempDir(),
+		"LANG=C",
+		"MALLOC_ARENA_MAX=2", // glibc arenas eat address space per thread under RLIMIT_AS
+		"MAGICK_CONFIGURE_PATH=" + magickDir,
+		fmt.Sprintf("MAGICK_MEMORY_LIMIT=%dMiB", limits.ToolMemoryMB/4),
+		fmt.Sprintf("MAGICK_MAP_LIMIT=%dMiB", limits.ToolMemoryMB/2),
+		fmt.Sprintf("MAGICK_TIME_LIMIT=%d", limits.ToolCPUSeconds),
+	}
+
+	return sandbox, nil
+}
+
+// Close - Remove the policy directory
+func (s *ToolSandbox) Close() {
+	os.RemoveAll(s.magickDir)
+}
+
+// Describe - One-line summary for .tools
+func (s *ToolSandbox) Describe() string {
+	if !sandboxSupported {
+		return "env + ImageMagick policy only (rlimits need Linux)"
+	}
+	return fmt.Sprintf("%dMB memory, %ds CPU, %dMB output per tool", s.MemoryBytes>>20, s.CPUSeconds, s.FileBytes>>20)
+}
+
+// Command - exec.Cmd for a tool with the sandbox environment and limits applied
+func (s *ToolSandbox) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := s.command(ctx, name, args...)
+	cmd.Env = s.env
+	return cmd
+}
+
+// limitViolation - Why a finished tool looks like it hit a limit, or "" for a normal failure
+func (s *ToolSandbox) limitViolation(state *os.ProcessState, output []byte) string {
+	if reason := s.signalViolation(state); reason != "" {
+		return reason
+	}
+	lower := strings.ToLower(string(output))
+	for _, hint := range limitOutputHints {
+		if strings.Contains(lower, hint) {
+			return hint
+		}
+	}
+	return ""
+}
+
+// toolReportKey - Context key for the per-job tool report
+type toolReportKey struct{}
+
+// toolReport - Set by runTool when any tool of a job hit a sandbox limit
+type toolReport struct {
+	tooComplex atomic.Bool
+}
+
+// withToolReport - Attach a fresh report to a job context
+func withToolReport(ctx context.Context) (context.Context, *toolReport) {
+	report := &toolReport{}
+	return context.WithValue(ctx, toolReportKey{}, report), report
+}
+
+// markMediaTooComplex - Record a limit violation on the job the context belongs to
+func markMediaTooComplex(ctx context.Context) {
+	if report, ok := ctx.Value(toolReportKey{}).(*toolReport); ok {
+		report.tooComplex.Store(true)
+	}
+}
+
+// TooComplex - Whether a tool of the job hit a sandbox limit
+func (r *toolReport) TooComplex() bool {
+	return r.tooComplex.Load()
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+// toolsandbox_linux.go - rlimit wrapper for tool subprocesses on Linux
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// sandboxSupported - rlimits are applied on this platform
+const sandboxSupported = true
+
+// command - Run the tool through our own binary, which sets rlimits and then execs the tool.
+// Limits are applied in the child before exec, so the parent bot process is never limited.
+func (s *ToolSandbox) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Printf("⚠️ Can't find own executable, running %s without rlimits: %v\n", name, err)
+		return exec.CommandContext(ctx, name, args...)
+	}
+
+	wrapped := append([]string{
+		sandboxArg,
+		strconv.FormatUint(s.MemoryBytes, 10),
+		strconv.FormatUint(s.CPUSeconds, 10),
+		strconv.FormatUint(s.FileBytes, 10),
+		name,
+	}, args...)
+	return exec.CommandContext(ctx, self, wrapped...)
+}
+
+// runSandboxChild - Entry point of the wrapper: apply the limits and replace ourselves with the tool.
+// Args: <memory bytes> <cpu seconds> <file bytes> <tool> [tool args...]
+func runSandboxChild(args []string) {
+	if len(args) < 4 {
+		fmt.Fprintln(os.Stderr, "tool sandbox: missing arguments")
+		os.Exit(127)
+	}
+
+	memory, errMemory := strconv.ParseUint(args[0], 10, 64)
+	cpu, errCPU := strconv.ParseUint(args[1], 10, 64)
+	fileSize, errFile := strconv.ParseUint(args[2], 10, 64)
+	if errMemory != nil || errCPU != nil || errFile != nil {
+		fmt.Fprintln(os.Stderr, "tool sandbox: invalid limits")
+		os.Exit(127)
+	}
+
+	limits := []struct {
+		resource int
+		limit    syscall.Rlimit
+	}{
+		{syscall.RLIMIT_AS, syscall.Rlimit{Cur: memory, Max: memory}},
+		{syscall.RLIMIT_CPU, syscall.Rlimit{Cur: cpu, Max: cpu + 5}}, // SIGXCPU first, SIGKILL 5s later
+		{syscall.RLIMIT_FSIZE, syscall.Rlimit{Cur: fileSize, Max: fileSize}},
+		{syscall.RLIMIT_CORE, syscall.Rlimit{Cur: 0, Max: 0}},
+	}
+	for _, l := range limits {
+		if err := syscall.Setrlimit(l.resource, &l.limit); err != nil {
+			fmt.Fprintf(os.Stderr, "tool sandbox: setrlimit %d: %v\n", l.resource, err)
+			os.Exit(127)
+		}
+	}
+
+	path, err := exec.LookPath(args[3])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tool sandbox: %v\n", err)
+		os.Exit(127)
+	}
+	err = syscall.Exec(path, args[3:], os.Environ())
+	fmt.Fprintf(os.Stderr, "tool sandbox: exec %s: %v\n", path, err)
+	os.Exit(127)
+}
+
+// signalViolation - Signals that mean an rlimit was exceeded. SIGKILL only counts when the tool
+// really used up its CPU time (the hard limit); crashes like SIGSEGV/SIGABRT are plain failures.
+func (s *ToolSandbox) signalViolation(state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return "cpu time limit"
+	case syscall.SIGKILL:
+		if s.CPUSeconds > 0 && state.UserTime()+state.SystemTime() >= time.Duration(s.CPUSeconds)*time.Second {
+			return "cpu time limit"
+		}
+	case syscall.SIGXFSZ:
+		return "output size limit"
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+// toolsandbox_other.go - Fallback for platforms without the rlimit wrapper
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// sandboxSupported - rlimits are not applied on this platform
+const sandboxSupported = false
+
+// command - Tools run directly; only the environment and ImageMagick policy apply
+func (s *ToolSandbox) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// runSandboxChild - Never reached here since command doesn't use the wrapper
+func runSandboxChild(args []string) {
+	os.Exit(127)
+}
+
+// signalViolation - No rlimit signals to inspect
+func (s *ToolSandbox) signalViolation(state *os.ProcessState) string {
+	return ""
+}
@@ -0,0 +1,185 @@
+// webpcodec.go - In-process WebP encode/decode for static stickers and .toimg
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/webp"
+)
+
+// WebP backends - native is pure Go and always available, cli uses cwebp/dwebp/ImageMagick
+const (
+	webpBackendNative = "native"
+	webpBackendCLI    = "cli"
+)
+
+// stickerSize - WhatsApp stickers are 512x512
+const stickerSize = 512
+
+// staticStickerMaxBytes - Size WhatsApp expects static stickers to stay under
+const staticStickerMaxBytes = 100 * 1024
+
+// posterizeSteps - Low bits dropped per channel when a lossless encode is too big
+var posterizeSteps = []uint{0, 1, 2, 3, 4}
+
+// webpBackend - Backend actually used for static WebP; cli falls back to native when cwebp is missing
+func (bot *WhatsAppBot) webpBackend() string {
+	if bot.config.Media.WebPBackend == webpBackendCLI && bot.isToolAvailable("cwebp") {
+		return webpBackendCLI
+	}
+	return webpBackendNative
+}
+
+// webpBackendLabel - Backend description for logs and .tools
+func (bot *WhatsAppBot) webpBackendLabel() string {
+	if bot.webpBackend() == webpBackendCLI {
+		return "cli (cwebp/dwebp, native as fallback)"
+	}
+	if bot.config.Media.WebPBackend == webpBackendCLI {
+		return "native (pure Go - cwebp not found, cli backend unavailable)"
+	}
+	return "native (pure Go)"
+}
+
+// isWebP - RIFF....WEBP header check
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// encodeStaticSticker - Lay out on the 512x512 canvas and encode a static sticker with the active backend
+func (bot *WhatsAppBot) encodeStaticSticker(ctx context.Context, img image.Image, opts StickerOptions) ([]byte, error) {
+	sticker := applyStickerLayout(img, opts, stickerSize)
+
+	if bot.webpBackend() == webpBackendCLI {
+		webpData, err := bot.encodeStaticWebPCLI(ctx, sticker, opts.QualityOr(80))
+		if err == nil {
+			return webpData, nil
+		}
+		fmt.Printf("⚠️ CLI WebP encode failed, using native encoder: %v\n", err)
+	}
+
+	return encodeWebPNative(sticker, staticStickerMaxBytes, opts.Quality)
+}
+
+// encodeStaticWebPCLI - cwebp first, ImageMagick second
+func (bot *WhatsAppBot) encodeStaticWebPCLI(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	tempDir, err := ioutil.TempDir("", "sticker_cli_*")
+	if err != nil {
+		return nil, fmt.Errorf("gagal create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	pngPath := filepath.Join(tempDir, "input.png")
+	outputPath := filepath.Join(tempDir, "sticker.webp")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("gagal encode temp PNG: %v", err)
+	}
+	if err := ioutil.WriteFile(pngPath, buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("gagal save temp PNG: %v", err)
+	}
+
+	webpData, err := bot.convertWithCWebPTool(ctx, pngPath, outputPath, quality)
+	if err != nil && bot.isToolAvailable("convert") {
+		fmt.Printf("⚠️ cwebp failed, trying ImageMagick...\n")
+		webpData, err = bot.convertWithImageMagickTool(ctx, pngPath, outputPath, quality)
+	}
+	return webpData, err
+}
+
+// encodeWebPNative - Lossless pure-Go encode; drops low color bits until it fits maxBytes.
+// A lower quality (1-100, 0 = best) starts with more bits dropped. Returns the smallest
+// attempt if nothing fits.
+func encodeWebPNative(img image.Image, maxBytes int, quality int) ([]byte, error) {
+	var smallest []byte
+	for _, bits := range posterizeSteps[posterizeStart(quality):] {
+		var buf bytes.Buffer
+		if err := nativewebp.Encode(&buf, posterize(img, bits), nil); err != nil {
+			return nil, fmt.Errorf("native webp encode failed: %v", err)
+		}
+
+		if smallest == nil || buf.Len() < len(smallest) {
+			smallest = buf.Bytes()
+		}
+		if buf.Len() <= maxBytes {
+			break
+		}
+		fmt.Printf("⚠️ Native WebP too large (%d bytes), reducing colors...\n", buf.Len())
+	}
+
+	fmt.Printf("✅ Native WebP encoded (%d bytes)\n", len(smallest))
+	return smallest, nil
+}
+
+// posterizeStart - First posterize step for a requested quality
+func posterizeStart(quality int) int {
+	switch {
+	case quality == 0 || quality >= 90:
+		return 0
+	case quality >= 70:
+		return 1
+	case quality >= 50:
+		return 2
+	case quality >= 30:
+		return 3
+	}
+	return 4
+}
+
+// decodeWebPNative - Decode a still WebP (VP8, VP8L or VP8X with alpha)
+func decodeWebPNative(data []byte) (image.Image, error) {
+	img, err := webp.Decode(bytes.NewReader(data))
+	if err == nil {
+		return img, nil
+	}
+
+	// x/image/webp rejects VP8X files that set the alpha flag over a VP8L bitstream, which is
+	// what libwebp writes for transparent lossless stickers. The VP8L chunk alone decodes fine.
+	if chunks, chunkErr := parseWebPChunks(data); chunkErr == nil {
+		for _, chunk := range chunks {
+			if chunk.FourCC == "ANIM" {
+				break
+			}
+			if chunk.FourCC == "VP8L" {
+				if img, lossless := webp.Decode(bytes.NewReader(buildWebP([]webpChunk{chunk}))); lossless == nil {
+					return img, nil
+				}
+				break
+			}
+		}
+	}
+	return nil, fmt.Errorf("native webp decode failed: %v", err)
+}
+
+// posterize - Drop the lowest bits of each color channel so the lossless encoder compresses better.
+// Fully transparent pixels are zeroed since their color is invisible anyway.
+func posterize(img image.Image, bits uint) image.Image {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	mask := uint8(0xFF << bits)
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			if c.A == 0 {
+				c = color.NRGBA{}
+			} else {
+				c.R &= mask
+				c.G &= mask
+				c.B &= mask
+			}
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out
+}
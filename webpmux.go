@@ -0,0 +1,363 @@
+// webpmux.go - RIFF chunk level reading and writing of WebP files, animated WebP muxing
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/webp"
+)
+
+// VP8X feature flags
+const (
+	vp8xFlagAnimation = 0x02
+	vp8xFlagXMP       = 0x04
+	vp8xFlagEXIF      = 0x08
+	vp8xFlagAlpha     = 0x10
+	vp8xFlagICC       = 0x20
+)
+
+// webpChunk - One RIFF chunk of a WebP file (payload without padding)
+type webpChunk struct {
+	FourCC string
+	Data   []byte
+}
+
+// parseWebPChunks - Split a WebP file into its top-level chunks
+func parseWebPChunks(data []byte) ([]webpChunk, error) {
+	if !isWebP(data) {
+		return nil, fmt.Errorf("bukan file WebP")
+	}
+
+	riffSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if riffSize < 4 {
+		return nil, fmt.Errorf("ukuran RIFF WebP ga valid (%d)", riffSize)
+	}
+	end := 8 + riffSize
+	if end > len(data) {
+		end = len(data) // tolerate truncated RIFF size
+	}
+
+	chunks, err := splitWebPChunks(data[12:end])
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("WebP tanpa chunk")
+	}
+	return chunks, nil
+}
+
+// splitWebPChunks - Chunks laid out back to back, as in the RIFF body or an ANMF payload
+func splitWebPChunks(data []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	offset := 0
+	for offset+8 <= len(data) {
+		fourCC := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		start := offset + 8
+		if size < 0 || start+size > len(data) {
+			return nil, fmt.Errorf("chunk %s kepotong", fourCC)
+		}
+		chunks = append(chunks, webpChunk{FourCC: fourCC, Data: data[start : start+size]})
+		offset = start + size + size%2
+	}
+	return chunks, nil
+}
+
+// buildWebP - Assemble chunks into a WebP file with a correct RIFF size
+func buildWebP(chunks []webpChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, chunk := range chunks {
+		writeWebPChunk(&body, chunk.FourCC, chunk.Data)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// writeWebPChunk - Chunk header, payload and padding byte for odd sizes
+func writeWebPChunk(buf *bytes.Buffer, fourCC string, data []byte) {
+	buf.WriteString(fourCC)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// webpBitstreamInfo - Size and alpha of a simple-format VP8 or VP8L chunk
+func webpBitstreamInfo(chunk webpChunk) (width, height int, alpha bool, err error) {
+	switch chunk.FourCC {
+	case "VP8L":
+		if len(chunk.Data) < 5 || chunk.Data[0] != 0x2f {
+			return 0, 0, false, fmt.Errorf("VP8L header tidak valid")
+		}
+		bits := binary.LittleEndian.Uint32(chunk.Data[1:5])
+		width = int(bits&0x3FFF) + 1
+		height = int((bits>>14)&0x3FFF) + 1
+		alpha = (bits>>28)&1 == 1
+		return width, height, alpha, nil
+
+	case "VP8 ":
+		if len(chunk.Data) < 10 || chunk.Data[3] != 0x9d || chunk.Data[4] != 0x01 || chunk.Data[5] != 0x2a {
+			return 0, 0, false, fmt.Errorf("VP8 header tidak valid")
+		}
+		width = int(binary.LittleEndian.Uint16(chunk.Data[6:8]) & 0x3FFF)
+		height = int(binary.LittleEndian.Uint16(chunk.Data[8:10]) & 0x3FFF)
+		return width, height, false, nil
+	}
+	return 0, 0, false, fmt.Errorf("chunk %s bukan bitstream gambar", chunk.FourCC)
+}
+
+// vp8xChunk - Extended format header for the given canvas and flags
+func vp8xChunk(flags byte, width, height int) webpChunk {
+	data := make([]byte, 10)
+	data[0] = flags
+	putUint24(data[4:7], uint32(width-1))
+	putUint24(data[7:10], uint32(height-1))
+	return webpChunk{FourCC: "VP8X", Data: data}
+}
+
+// toExtendedWebP - Make sure the file starts with a VP8X chunk so optional chunks can be added
+func toExtendedWebP(chunks []webpChunk) ([]webpChunk, error) {
+	if chunks[0].FourCC == "VP8X" {
+		if len(chunks[0].Data) < 10 {
+			return nil, fmt.Errorf("VP8X header tidak valid")
+		}
+		return chunks, nil
+	}
+
+	width, height, alpha, err := webpBitstreamInfo(chunks[0])
+	if err != nil {
+		return nil, err
+	}
+	var flags byte
+	if alpha {
+		flags |= vp8xFlagAlpha
+	}
+	return append([]webpChunk{vp8xChunk(flags, width, height)}, chunks...), nil
+}
+
+// putUint24 - Little endian 24-bit value, used by VP8X and ANMF headers
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// getUint24 - Read a little endian 24-bit value
+func getUint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// webpFrame - One frame of an animated WebP, drawn at full canvas size
+type webpFrame struct {
+	Image    image.Image
+	Duration time.Duration
+}
+
+// encodeAnimatedWebPNative - Encode frames losslessly and mux them into an animated WebP.
+// Frames replace each other without blending, so transparent areas don't show older frames.
+func encodeAnimatedWebPNative(frames []webpFrame, loopCount int) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("animasi tanpa frame")
+	}
+	bounds := frames[0].Image.Bounds()
+
+	chunks := []webpChunk{
+		vp8xChunk(vp8xFlagAnimation|vp8xFlagAlpha, bounds.Dx(), bounds.Dy()),
+		animChunk(loopCount),
+	}
+	for i, frame := range frames {
+		var buf bytes.Buffer
+		if err := nativewebp.Encode(&buf, frame.Image, nil); err != nil {
+			return nil, fmt.Errorf("frame %d: native webp encode failed: %v", i+1, err)
+		}
+		frameChunks, err := parseWebPChunks(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %v", i+1, err)
+		}
+		chunks = append(chunks, anmfChunk(frameChunks, frame.Image.Bounds(), frame.Duration))
+	}
+
+	return buildWebP(chunks), nil
+}
+
+// animChunk - Global animation parameters: transparent background and loop count (0 = forever)
+func animChunk(loopCount int) webpChunk {
+	data := make([]byte, 6)
+	binary.LittleEndian.PutUint16(data[4:6], uint16(loopCount))
+	return webpChunk{FourCC: "ANIM", Data: data}
+}
+
+// anmfChunk - Wrap a frame's bitstream chunks (ALPH/VP8/VP8L) into an ANMF chunk
+func anmfChunk(frameChunks []webpChunk, rect image.Rectangle, duration time.Duration) webpChunk {
+	var buf bytes.Buffer
+	header := make([]byte, 16)
+	putUint24(header[0:3], uint32(rect.Min.X/2))
+	putUint24(header[3:6], uint32(rect.Min.Y/2))
+	putUint24(header[6:9], uint32(rect.Dx()-1))
+	putUint24(header[9:12], uint32(rect.Dy()-1))
+	putUint24(header[12:15], uint32(duration/time.Millisecond))
+	header[15] = 0x02 // do not blend, no disposal
+	buf.Write(header)
+
+	for _, chunk := range frameChunks {
+		switch chunk.FourCC {
+		case "ALPH", "VP8 ", "VP8L":
+			writeWebPChunk(&buf, chunk.FourCC, chunk.Data)
+		}
+	}
+	return webpChunk{FourCC: "ANMF", Data: buf.Bytes()}
+}
+
+// Limits for decoding animated stickers. Every decoded frame is a full NRGBA canvas, so the
+// budget is on frames x canvas bytes; a tiny file repeating one ANMF can't blow past it.
+const (
+	maxAnimationFrames = 500
+	maxAnimationPixels = 2048 * 2048
+	maxAnimationBytes  = 128 << 20
+)
+
+// isAnimatedWebP - WebP with ANIM/ANMF chunks (dwebp and x/image/webp only see the first frame)
+func isAnimatedWebP(data []byte) bool {
+	chunks, err := parseWebPChunks(data)
+	if err != nil {
+		return false
+	}
+	for _, chunk := range chunks {
+		if chunk.FourCC == "ANIM" || chunk.FourCC == "ANMF" {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeAnimatedWebP - Demux the ANMF frames and composite them onto the canvas the way players do,
+// honoring each frame's offset, blending and disposal. Every returned frame is a full canvas.
+func decodeAnimatedWebP(data []byte) ([]webpFrame, error) {
+	chunks, err := parseWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	if chunks[0].FourCC != "VP8X" || len(chunks[0].Data) < 10 {
+		return nil, fmt.Errorf("WebP animasi tanpa header VP8X")
+	}
+	width := int(getUint24(chunks[0].Data[4:7])) + 1
+	height := int(getUint24(chunks[0].Data[7:10])) + 1
+	if width*height > maxAnimationPixels {
+		return nil, fmt.Errorf("kanvas animasi kegedean (%dx%d)", width, height)
+	}
+
+	frameBytes := width * height * 4
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+	var frames []webpFrame
+	var disposeRect image.Rectangle
+	for _, chunk := range chunks {
+		if chunk.FourCC != "ANMF" {
+			continue
+		}
+		if len(frames) >= maxAnimationFrames {
+			fmt.Printf("⚠️ Animation has more than %d frames, rest skipped\n", maxAnimationFrames)
+			break
+		}
+		if (len(frames)+1)*frameBytes > maxAnimationBytes {
+			fmt.Printf("⚠️ Animation over %d MB decoded at frame %d (%dx%d), rest skipped\n",
+				maxAnimationBytes>>20, len(frames)+1, width, height)
+			break
+		}
+		if len(chunk.Data) < 16 {
+			return nil, fmt.Errorf("frame %d: header ANMF kepotong", len(frames)+1)
+		}
+
+		header := chunk.Data[:16]
+		x := int(getUint24(header[0:3])) * 2
+		y := int(getUint24(header[3:6])) * 2
+		frameWidth := int(getUint24(header[6:9])) + 1
+		frameHeight := int(getUint24(header[9:12])) + 1
+		duration := time.Duration(getUint24(header[12:15])) * time.Millisecond
+		blend := header[15]&0x02 == 0
+		dispose := header[15]&0x01 == 1
+		if x+frameWidth > width || y+frameHeight > height {
+			return nil, fmt.Errorf("frame %d keluar dari kanvas (%d,%d %dx%d di %dx%d)",
+				len(frames)+1, x, y, frameWidth, frameHeight, width, height)
+		}
+
+		// The previous frame's disposal happens right before this one is drawn
+		draw.Draw(canvas, disposeRect, image.Transparent, image.Point{}, draw.Src)
+
+		frameChunks, err := splitWebPChunks(chunk.Data[16:])
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %v", len(frames)+1, err)
+		}
+		img, err := decodeWebPFrame(frameChunks, frameWidth, frameHeight)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %v", len(frames)+1, err)
+		}
+
+		rect := image.Rect(x, y, x+frameWidth, y+frameHeight)
+		op := draw.Src
+		if blend {
+			op = draw.Over
+		}
+		draw.Draw(canvas, rect, img, img.Bounds().Min, op)
+
+		snapshot := image.NewNRGBA(canvas.Bounds())
+		copy(snapshot.Pix, canvas.Pix)
+		frames = append(frames, webpFrame{Image: snapshot, Duration: duration})
+
+		disposeRect = image.Rectangle{}
+		if dispose {
+			disposeRect = rect
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("WebP animasi tanpa frame")
+	}
+	return frames, nil
+}
+
+// decodeWebPFrame - Decode the ALPH/VP8/VP8L chunks of one ANMF frame as a standalone WebP
+func decodeWebPFrame(chunks []webpChunk, width, height int) (image.Image, error) {
+	var alpha, bitstream *webpChunk
+	for i := range chunks {
+		switch chunks[i].FourCC {
+		case "ALPH":
+			alpha = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, fmt.Errorf("frame tanpa bitstream gambar")
+	}
+
+	// The decoder allocates for the bitstream's own size, so it must match the ANMF box
+	// the memory budget was checked against
+	bitstreamWidth, bitstreamHeight, _, err := webpBitstreamInfo(*bitstream)
+	if err != nil {
+		return nil, err
+	}
+	if bitstreamWidth != width || bitstreamHeight != height {
+		return nil, fmt.Errorf("ukuran bitstream %dx%d beda dari frame %dx%d",
+			bitstreamWidth, bitstreamHeight, width, height)
+	}
+
+	// Lossless frames carry their own alpha; lossy ones need VP8X + ALPH in front
+	file := []webpChunk{*bitstream}
+	if bitstream.FourCC == "VP8 " && alpha != nil {
+		file = []webpChunk{vp8xChunk(vp8xFlagAlpha, width, height), *alpha, *bitstream}
+	}
+	return webp.Decode(bytes.NewReader(buildWebP(file)))
+}